@@ -2,23 +2,51 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"imgcutter/router"
 	"imgcutter/service"
+	"imgcutter/static"
 
 	_ "image/jpeg"
 	_ "image/png"
 )
 
+const presetsFile = "configs/presets.json"
+
+// loadPresets reads presets file of overrideDir if it has one, otherwise the built-in one.
+func loadPresets(overrideDir string) ([]service.Preset, error) {
+	if overrideDir != "" {
+		if _, err := os.Stat(filepath.Join(overrideDir, presetsFile)); err == nil {
+			return service.LoadPresets(os.DirFS(overrideDir), presetsFile)
+		}
+	}
+
+	return service.LoadPresets(static.Configs, presetsFile)
+}
+
 func main() {
-	services := service.NewService()
-	r, err := router.NewRouter(services)
+	var opts router.Options
+
+	flag.StringVar(&opts.BasePath, "base-path", os.Getenv("BASE_PATH"), "path prefix the service is served under, like /cutter")
+	flag.BoolVar(&opts.TrustProxy, "trust-proxy", os.Getenv("TRUST_PROXY") == "true", "honour X-Forwarded-* headers of reverse proxy")
+	flag.StringVar(&opts.OverrideDir, "override-dir", os.Getenv("OVERRIDE_DIR"), "directory with templates, assets and app files replacing built-in ones")
+	flag.Parse()
+
+	presets, err := loadPresets(opts.OverrideDir)
+	if err != nil {
+		log.Printf("built-in presets not loaded: %v", err)
+	}
+
+	services := service.NewService(presets)
+	r, err := router.NewRouter(services, opts)
 	if err != nil {
 		log.Println(err)
 		return
@@ -0,0 +1,178 @@
+// Package i18n translates messages of web UI with catalogs of locales.
+package i18n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default locale is used if client asks for none of supported ones,
+// its catalog is the fallback for messages missing in other catalogs.
+const Default = "en"
+
+var (
+	ErrNoCatalogs     = errors.New("no message catalogs")
+	ErrMissingMessage = errors.New("missing message")
+)
+
+// Bundle holds catalogs of all supported locales, a catalog maps message keys to messages.
+type Bundle struct {
+	catalogs map[string]map[string]string
+}
+
+// Load reads catalogs from "<locale>.json" files in root of fsys, like "en.json" and "ru.json".
+func Load(fsys fs.FS) (*Bundle, error) {
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bundle{catalogs: make(map[string]map[string]string, len(names))}
+
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("bad catalog %s: %w", name, err)
+		}
+
+		b.catalogs[strings.ToLower(strings.TrimSuffix(name, path.Ext(name)))] = catalog
+	}
+
+	if _, ok := b.catalogs[Default]; !ok {
+		return nil, fmt.Errorf("%w: %s.json is required", ErrNoCatalogs, Default)
+	}
+
+	return b, nil
+}
+
+// Locales returns supported locales in alphabetical order.
+func (b *Bundle) Locales() []string {
+	locales := make([]string, 0, len(b.catalogs))
+	for l := range b.catalogs {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+
+	return locales
+}
+
+// Has tells if locale is supported.
+func (b *Bundle) Has(locale string) bool {
+	_, ok := b.catalogs[locale]
+	return ok
+}
+
+// Match picks supported locale for Accept-Language header like "ru-RU,ru;q=0.9,en;q=0.8".
+// A region falls back to its language, Default is returned if nothing matches.
+func (b *Bundle) Match(acceptLanguage string) string {
+	type choice struct {
+		tag string
+		q   float64
+	}
+
+	choices := make([]choice, 0)
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		c := choice{tag: strings.ToLower(strings.TrimSpace(tag)), q: 1}
+
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			q, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			if err != nil {
+				continue
+			}
+			c.q = q
+		}
+
+		if c.tag != "" && c.q > 0 {
+			choices = append(choices, c)
+		}
+	}
+
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+
+	for _, c := range choices {
+		lang, _, _ := strings.Cut(c.tag, "-")
+
+		switch {
+		case b.Has(c.tag):
+			return c.tag
+		case b.Has(lang):
+			return lang
+		case c.tag == "*":
+			return Default
+		}
+	}
+
+	return Default
+}
+
+// Translate returns message of key in locale formatted with args like fmt.Sprintf.
+// Messages missing in locale are taken from Default, error means key is unknown.
+func (b *Bundle) Translate(locale, key string, args ...any) (string, error) {
+	msg, ok := b.catalogs[locale][key]
+	if !ok {
+		msg, ok = b.catalogs[Default][key]
+	}
+
+	if !ok {
+		return key, fmt.Errorf("%w: %q", ErrMissingMessage, key)
+	}
+
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+
+	return msg, nil
+}
+
+// T is Translate that returns key itself for unknown keys.
+func (b *Bundle) T(locale, key string, args ...any) string {
+	msg, _ := b.Translate(locale, key, args...)
+	return msg
+}
+
+// Catalog returns all messages of locale, messages missing in it are taken from Default.
+// Messages are not formatted, so frontend may format them itself.
+func (b *Bundle) Catalog(locale string) map[string]string {
+	out := make(map[string]string, len(b.catalogs[Default]))
+	for key, msg := range b.catalogs[Default] {
+		out[key] = msg
+	}
+
+	for key, msg := range b.catalogs[locale] {
+		out[key] = msg
+	}
+
+	return out
+}
+
+// Check makes sure every catalog has the same keys as Default one.
+func (b *Bundle) Check() error {
+	for _, locale := range b.Locales() {
+		for key := range b.catalogs[Default] {
+			if _, ok := b.catalogs[locale][key]; !ok {
+				return fmt.Errorf("%w: %q in %s", ErrMissingMessage, key, locale)
+			}
+		}
+
+		for key := range b.catalogs[locale] {
+			if _, ok := b.catalogs[Default][key]; !ok {
+				return fmt.Errorf("%w: %q of %s in %s", ErrMissingMessage, key, locale, Default)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package i18n
+
+import (
+	"errors"
+	"io/fs"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"imgcutter/static"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func testBundle(t *testing.T) *Bundle {
+	b, err := Load(fstest.MapFS{
+		"en.json": {Data: []byte(`{"hello": "Hello, %s", "bye": "Bye"}`)},
+		"ru.json": {Data: []byte(`{"hello": "Привет, %s"}`)},
+	})
+	assert.Equal(t, err, nil)
+
+	return b
+}
+
+func TestLoad(t *testing.T) {
+	b := testBundle(t)
+	assert.Equal(t, b.Locales(), []string{"en", "ru"})
+	assert.Equal(t, b.Has("ru"), true)
+	assert.Equal(t, b.Has("de"), false)
+
+	_, err := Load(fstest.MapFS{"ru.json": {Data: []byte(`{}`)}})
+	assert.Equal(t, errors.Is(err, ErrNoCatalogs), true)
+
+	_, err = Load(fstest.MapFS{"en.json": {Data: []byte(`[`)}})
+	assert.Equal(t, err != nil, true)
+}
+
+func TestBundle_Match(t *testing.T) {
+	b := testBundle(t)
+
+	testCases := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{acceptLanguage: "", want: "en"},
+		{acceptLanguage: "ru", want: "ru"},
+		{acceptLanguage: "ru-RU,ru;q=0.9,en;q=0.8", want: "ru"},
+		{acceptLanguage: "de-DE, en;q=0.5, ru;q=0.7", want: "ru"},
+		{acceptLanguage: "de, fr;q=0.5", want: "en"},
+		{acceptLanguage: "de, *;q=0.5", want: "en"},
+		{acceptLanguage: "ru;q=0, en", want: "en"},
+		{acceptLanguage: "ru;q=bad, en", want: "en"},
+		{acceptLanguage: "RU-ru", want: "ru"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, b.Match(tc.acceptLanguage), tc.want, tc.acceptLanguage)
+	}
+}
+
+func TestBundle_Translate(t *testing.T) {
+	b := testBundle(t)
+
+	msg, err := b.Translate("ru", "hello", "мир")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, msg, "Привет, мир")
+
+	// нет в русском каталоге, берётся из английского
+	msg, err = b.Translate("ru", "bye")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, msg, "Bye")
+
+	msg, err = b.Translate("de", "hello", "world")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, msg, "Hello, world")
+
+	_, err = b.Translate("en", "unknown")
+	assert.Equal(t, errors.Is(err, ErrMissingMessage), true)
+	assert.Equal(t, b.T("en", "unknown"), "unknown")
+
+	assert.Equal(t, errors.Is(b.Check(), ErrMissingMessage), true)
+}
+
+func TestBundle_Catalog(t *testing.T) {
+	b := testBundle(t)
+
+	assert.Equal(t, b.Catalog("ru"), map[string]string{"hello": "Привет, %s", "bye": "Bye"})
+	assert.Equal(t, b.Catalog("de"), map[string]string{"hello": "Hello, %s", "bye": "Bye"})
+}
+
+// Catalogs of web UI must be complete, otherwise part of pages stays untranslated.
+func TestCatalogs(t *testing.T) {
+	locales, err := fs.Sub(static.Locales, "locales")
+	assert.Equal(t, err, nil)
+
+	b, err := Load(locales)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, b.Locales(), []string{"en", "ru"})
+	assert.Equal(t, b.Check(), nil)
+}
+
+// appKeys finds keys of messages used by single page frontend:
+// data-i18n attributes of markup and calls of t in script.
+var appKeys = regexp.MustCompile(`data-i18n(?:-alt)?="([^"]+)"|\bt\("([^"]+)"`)
+
+// Frontend translates itself with catalogs too, every key it uses must be there.
+func TestCatalogs_App(t *testing.T) {
+	locales, err := fs.Sub(static.Locales, "locales")
+	assert.Equal(t, err, nil)
+
+	b, err := Load(locales)
+	assert.Equal(t, err, nil)
+
+	for _, name := range []string{"app/index.html", "app/app.js"} {
+		src, err := fs.ReadFile(static.App, name)
+		assert.Equal(t, err, nil)
+
+		found := appKeys.FindAllStringSubmatch(string(src), -1)
+		assert.Equal(t, len(found) > 0, true, name)
+
+		// полноту остальных каталогов проверяет Check
+		for _, m := range found {
+			_, err := b.Translate(Default, m[1]+m[2])
+			assert.Equal(t, err, nil, name+": "+m[1]+m[2])
+		}
+	}
+}
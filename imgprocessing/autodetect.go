@@ -0,0 +1,365 @@
+package imgprocessing
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrBadColor      = errors.New("bad color")
+	ErrUnknownDetect = errors.New("unknown detect method")
+	ErrNothingToCut  = errors.New("no sprites found")
+	ErrBadTolerance  = errors.New("tolerance must be in range 0..255")
+)
+
+// minSpriteArea in px, smaller regions are treated as noise.
+const minSpriteArea = 4
+
+// DetectMethod selects how DetectSprites splits a sheet.
+type DetectMethod string
+
+const (
+	// DetectRegions finds connected groups of non-background pixels (8-connectivity),
+	// bounding boxes that overlap are merged.
+	DetectRegions DetectMethod = "regions"
+	// DetectGutters splits sheet by rows and then columns made only of background,
+	// like a grid with irregular cells.
+	DetectGutters DetectMethod = "gutters"
+)
+
+func ParseDetectMethod(s string) (DetectMethod, error) {
+	switch DetectMethod(s) {
+	case "", DetectRegions:
+		return DetectRegions, nil
+	case DetectGutters:
+		return DetectGutters, nil
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrUnknownDetect, s)
+}
+
+// Background tells which pixels are empty.
+type Background struct {
+	Color       color.NRGBA // ignored if Transparent
+	Transparent bool        // pixels with alpha not above Tolerance are background
+	Auto        bool        // take color of top left pixel
+	Tolerance   uint8       // max difference of every channel
+}
+
+// ParseBackground accepts "", "auto", "transparent", "#rgb" or "#rrggbb".
+func ParseBackground(s string, tolerance int) (Background, error) {
+	if tolerance < 0 || tolerance > 255 {
+		return Background{}, ErrBadTolerance
+	}
+
+	bg := Background{Tolerance: uint8(tolerance)}
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	switch s {
+	case "", "auto":
+		bg.Auto = true
+		return bg, nil
+	case "transparent":
+		bg.Transparent = true
+		return bg, nil
+	}
+
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return Background{}, fmt.Errorf("%w: %q", ErrBadColor, s)
+	}
+
+	bg.Color = color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}
+
+	return bg, nil
+}
+
+// resolve replaces Auto with color of top left pixel, transparent one means transparent background.
+func (bg Background) resolve(img image.Image) Background {
+	if !bg.Auto {
+		return bg
+	}
+
+	bg.Auto = false
+	c := color.NRGBAModel.Convert(img.At(img.Bounds().Min.X, img.Bounds().Min.Y)).(color.NRGBA)
+
+	if c.A < 255 {
+		bg.Transparent = true
+	} else {
+		bg.Color = c
+	}
+
+	return bg
+}
+
+// isBackground must be called on resolved Background.
+func (bg Background) isBackground(c color.Color) bool {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+
+	if bg.Transparent {
+		return n.A <= bg.Tolerance
+	}
+
+	return diff(n.R, bg.Color.R) <= bg.Tolerance && diff(n.G, bg.Color.G) <= bg.Tolerance &&
+		diff(n.B, bg.Color.B) <= bg.Tolerance && diff(n.A, bg.Color.A) <= bg.Tolerance
+}
+
+func diff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+
+	return b - a
+}
+
+// DetectSprites returns bounding boxes of sprites in reading order: top to bottom, left to right.
+func DetectSprites(img image.Image, method DetectMethod, bg Background) ([]image.Rectangle, error) {
+	bg = bg.resolve(img)
+	b := img.Bounds()
+	mask := make([]bool, b.Dx()*b.Dy()) // true for sprite pixels
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			mask[(y-b.Min.Y)*b.Dx()+(x-b.Min.X)] = !bg.isBackground(img.At(x, y))
+		}
+	}
+
+	var rects []image.Rectangle
+
+	switch method {
+	case DetectRegions:
+		// шум отбрасывается до слияния, иначе на зашумлённом листе компонент будут сотни тысяч
+		rects = mergeOverlapping(dropSmall(connectedRegions(mask, b.Dx(), b.Dy())))
+	case DetectGutters:
+		rects = gutterCells(mask, b.Dx(), b.Dy())
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDetect, method)
+	}
+
+	out := make([]image.Rectangle, 0, len(rects))
+	for _, r := range dropSmall(rects) {
+		out = append(out, r.Add(b.Min))
+	}
+
+	if len(out) == 0 {
+		return nil, ErrNothingToCut
+	}
+
+	return readingOrder(out), nil
+}
+
+// dropSmall removes boxes smaller than minSpriteArea in place.
+func dropSmall(rects []image.Rectangle) []image.Rectangle {
+	out := rects[:0]
+	for _, r := range rects {
+		if r.Dx()*r.Dy() >= minSpriteArea {
+			out = append(out, r)
+		}
+	}
+
+	return out
+}
+
+// readingOrder groups boxes into lines of vertically overlapping boxes and sorts lines
+// top to bottom, boxes in a line left to right.
+func readingOrder(rects []image.Rectangle) []image.Rectangle {
+	sort.Slice(rects, func(i, j int) bool { return rects[i].Min.Y < rects[j].Min.Y })
+
+	out := make([]image.Rectangle, 0, len(rects))
+
+	for start := 0; start < len(rects); {
+		end, bottom := start+1, rects[start].Max.Y
+		for end < len(rects) && rects[end].Min.Y < bottom {
+			bottom = maxInt(bottom, rects[end].Max.Y)
+			end++
+		}
+
+		line := rects[start:end]
+		sort.Slice(line, func(i, j int) bool { return line[i].Min.X < line[j].Min.X })
+		out = append(out, line...)
+		start = end
+	}
+
+	return out
+}
+
+// CutRects cuts img into pieces with given rectangles, pieces share pixels with img.
+func CutRects(img image.Image, rects []image.Rectangle) ([]image.Image, error) {
+	subImager, err := castSubImager(img)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]image.Image, len(rects))
+	for i, r := range rects {
+		images[i] = subImager.SubImage(r)
+	}
+
+	return images, nil
+}
+
+func connectedRegions(mask []bool, w, h int) []image.Rectangle {
+	visited := make([]bool, len(mask))
+	queue := make([]int, 0)
+	rects := make([]image.Rectangle, 0)
+
+	for start := range mask {
+		if !mask[start] || visited[start] {
+			continue
+		}
+
+		visited[start] = true
+		queue = append(queue[:0], start)
+		r := image.Rect(start%w, start/w, start%w+1, start/w+1)
+
+		for len(queue) > 0 {
+			p := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			px, py := p%w, p/w
+			r = r.Union(image.Rect(px, py, px+1, py+1))
+
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					nx, ny := px+dx, py+dy
+					if nx < 0 || ny < 0 || nx >= w || ny >= h {
+						continue
+					}
+
+					n := ny*w + nx
+					if mask[n] && !visited[n] {
+						visited[n] = true
+						queue = append(queue, n)
+					}
+				}
+			}
+		}
+
+		rects = append(rects, r)
+	}
+
+	return rects
+}
+
+// mergeOverlapping joins boxes until none overlap, so detached parts of a sprite
+// lying inside its box (like eyes of a face) do not become sprites of their own.
+// Every pass sweeps boxes sorted by left edge and compares each one only with boxes
+// still reaching it, passes repeat while grown boxes find new overlaps.
+func mergeOverlapping(rects []image.Rectangle) []image.Rectangle {
+	for merged := true; merged; {
+		merged = false
+		sort.Slice(rects, func(i, j int) bool { return rects[i].Min.X < rects[j].Min.X })
+
+		out := make([]image.Rectangle, 0, len(rects))
+		active := make([]int, 0) // indexes in out of boxes to the right of sweep line
+
+		for _, r := range rects {
+			sweep := r.Min.X
+			next := active[:0]
+
+			for _, i := range active {
+				switch {
+				case out[i].Max.X <= sweep:
+					// дальше боксы начинаются правее, этот уже никого не заденет
+				case out[i].Overlaps(r):
+					r = r.Union(out[i])
+					out[i] = image.Rectangle{}
+					merged = true
+				default:
+					next = append(next, i)
+				}
+			}
+
+			active = append(next, len(out))
+			out = append(out, r)
+		}
+
+		rects = rects[:0]
+		for _, r := range out {
+			if !r.Empty() {
+				rects = append(rects, r)
+			}
+		}
+	}
+
+	return rects
+}
+
+func gutterCells(mask []bool, w, h int) []image.Rectangle {
+	rows := runs(h, func(y int) bool {
+		for x := 0; x < w; x++ {
+			if mask[y*w+x] {
+				return true
+			}
+		}
+
+		return false
+	})
+
+	rects := make([]image.Rectangle, 0)
+
+	for _, row := range rows {
+		cols := runs(w, func(x int) bool {
+			for y := row[0]; y < row[1]; y++ {
+				if mask[y*w+x] {
+					return true
+				}
+			}
+
+			return false
+		})
+
+		for _, col := range cols {
+			rects = append(rects, trim(mask, w, image.Rect(col[0], row[0], col[1], row[1])))
+		}
+	}
+
+	return rects
+}
+
+// runs returns [start, end) ranges of consecutive i for which filled(i) is true.
+func runs(n int, filled func(i int) bool) [][2]int {
+	out := make([][2]int, 0)
+	start := -1
+
+	for i := 0; i <= n; i++ {
+		if i < n && filled(i) {
+			if start < 0 {
+				start = i
+			}
+
+			continue
+		}
+
+		if start >= 0 {
+			out = append(out, [2]int{start, i})
+			start = -1
+		}
+	}
+
+	return out
+}
+
+// trim shrinks r to bounding box of sprite pixels inside it.
+func trim(mask []bool, w int, r image.Rectangle) image.Rectangle {
+	out := image.Rectangle{}
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if mask[y*w+x] {
+				out = out.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+
+	return out
+}
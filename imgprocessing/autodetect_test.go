@@ -0,0 +1,128 @@
+package imgprocessing
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestDetectSprites(t *testing.T) {
+	sprites := []image.Rectangle{
+		image.Rect(5, 5, 25, 15),
+		image.Rect(40, 2, 50, 30),
+		image.Rect(3, 40, 60, 50),
+	}
+
+	transparent := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	white := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	draw.Draw(white, white.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	blank := image.NewGray(image.Rect(0, 0, 64, 64))
+
+	for _, r := range sprites {
+		draw.Draw(transparent, r, image.NewUniform(color.NRGBA{R: 200, A: 255}), image.Point{}, draw.Src)
+		draw.Draw(white, r, image.NewUniform(color.NRGBA{R: 200, A: 255}), image.Point{}, draw.Src)
+	}
+
+	// островок внутри дырки первого спрайта не должен стать отдельным спрайтом
+	draw.Draw(transparent, image.Rect(8, 7, 22, 13), image.Transparent, image.Point{}, draw.Src)
+	draw.Draw(transparent, image.Rect(14, 9, 16, 11), image.NewUniform(color.NRGBA{G: 200, A: 255}), image.Point{}, draw.Src)
+
+	testCases := []struct {
+		name       string
+		img        image.Image
+		method     DetectMethod
+		background string
+		want       []image.Rectangle
+		wantErr    error
+	}{
+		{name: "regions transparent", img: transparent, method: DetectRegions, want: sprites},
+		{name: "gutters transparent", img: transparent, method: DetectGutters, want: sprites},
+		{name: "regions white", img: white, method: DetectRegions, background: "#fff", want: sprites},
+		{name: "gutters auto", img: white, method: DetectGutters, background: "auto", want: sprites},
+		{name: "nothing found", img: blank, method: DetectRegions, background: "#000", wantErr: ErrNothingToCut},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bg, err := ParseBackground(tc.background, 0)
+			assert.Equal(t, err, nil)
+
+			rects, err := DetectSprites(tc.img, tc.method, bg)
+			assert.Equal(t, err, tc.wantErr)
+			assert.Equal(t, rects, tc.want)
+		})
+	}
+}
+
+func TestMergeOverlapping(t *testing.T) {
+	testCases := []struct {
+		name  string
+		rects []image.Rectangle
+		want  []image.Rectangle
+	}{
+		{
+			name:  "apart",
+			rects: []image.Rectangle{image.Rect(10, 0, 20, 10), image.Rect(0, 0, 10, 10)},
+			want:  []image.Rectangle{image.Rect(0, 0, 10, 10), image.Rect(10, 0, 20, 10)},
+		},
+		{
+			name:  "inside",
+			rects: []image.Rectangle{image.Rect(0, 0, 10, 10), image.Rect(2, 2, 4, 4)},
+			want:  []image.Rectangle{image.Rect(0, 0, 10, 10)},
+		},
+		{
+			// объединение первых двух задевает третий, хотя по отдельности они его не касаются
+			name:  "grown box",
+			rects: []image.Rectangle{image.Rect(0, 0, 4, 4), image.Rect(2, 2, 10, 6), image.Rect(0, 5, 2, 8)},
+			want:  []image.Rectangle{image.Rect(0, 0, 10, 8)},
+		},
+		{
+			name:  "closed box reached again",
+			rects: []image.Rectangle{image.Rect(0, 20, 3, 23), image.Rect(2, 0, 8, 4), image.Rect(6, 2, 12, 22)},
+			want:  []image.Rectangle{image.Rect(0, 0, 12, 23)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, mergeOverlapping(tc.rects), tc.want)
+		})
+	}
+}
+
+func TestDetectSprites_Noise(t *testing.T) {
+	// зашумлённый лист: отдельные точки и пары точек, как артефакты jpeg
+	img := image.NewNRGBA(image.Rect(0, 0, 2000, 2000))
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 200000; i++ {
+		img.Set(rnd.Intn(2000), rnd.Intn(2000), color.Black)
+	}
+
+	draw.Draw(img, image.Rect(100, 100, 300, 300), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+	start := time.Now()
+	rects, err := DetectSprites(img, DetectRegions, Background{Transparent: true})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(rects) > 0, true)
+	assert.Equal(t, time.Since(start) < 10*time.Second, true, time.Since(start).String())
+}
+
+func TestParseBackground(t *testing.T) {
+	bg, err := ParseBackground("#0a0B0c", 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, bg.Color, color.NRGBA{R: 10, G: 11, B: 12, A: 255})
+
+	for _, s := range []string{"#12", "red", "#gggggg", "#1234567"} {
+		_, err := ParseBackground(s, 0)
+		assert.Equal(t, err != nil, true)
+	}
+
+	_, err = ParseBackground("", 256)
+	assert.Equal(t, err, ErrBadTolerance)
+}
@@ -0,0 +1,60 @@
+package imgprocessing
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// SkippedTile is a blank tile left out of archive by PackImages.
+type SkippedTile struct {
+	Row    int    `json:"row"`
+	Col    int    `json:"col"`
+	Index  int    `json:"index"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Color  string `json:"color"` // "#rrggbbaa" of top left pixel, Stitch fills tile with it
+}
+
+// IsBlank tells if every pixel of img is background. With Auto background a tile
+// is blank if it is uniform: all pixels are close to its top left one.
+func IsBlank(img image.Image, bg Background) bool {
+	b := img.Bounds()
+	if b.Empty() {
+		return true
+	}
+
+	bg = bg.resolve(img)
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if !bg.isBackground(img.At(x, y)) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func skippedTile(e TileEntry, img image.Image) SkippedTile {
+	c := color.NRGBAModel.Convert(img.At(e.Bounds.Min.X, e.Bounds.Min.Y)).(color.NRGBA)
+
+	return SkippedTile{
+		Row: e.Row, Col: e.Col, Index: e.Index,
+		X: e.Bounds.Min.X, Y: e.Bounds.Min.Y, Width: e.Bounds.Dx(), Height: e.Bounds.Dy(),
+		Color: fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A),
+	}
+}
+
+// parseSkippedColor reads color written by skippedTile, anything else gives transparent.
+func parseSkippedColor(s string) color.NRGBA {
+	var c color.NRGBA
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x%02x", &c.R, &c.G, &c.B, &c.A); err != nil {
+		return color.NRGBA{}
+	}
+
+	return c
+}
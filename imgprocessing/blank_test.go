@@ -0,0 +1,81 @@
+package imgprocessing
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestIsBlank(t *testing.T) {
+	white := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	draw.Draw(white, white.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	noisy := image.NewNRGBA(white.Bounds())
+	draw.Draw(noisy, noisy.Bounds(), white, image.Point{}, draw.Src)
+	noisy.SetNRGBA(3, 3, color.NRGBA{R: 250, G: 250, B: 250, A: 255})
+
+	transparent := image.NewNRGBA(white.Bounds())
+
+	tests := []struct {
+		name  string
+		img   image.Image
+		bg    string
+		tol   int
+		blank bool
+	}{
+		{name: "white on white", img: white, bg: "#fff", blank: true},
+		{name: "white on black", img: white, bg: "#000", blank: false},
+		{name: "noise above tolerance", img: noisy, bg: "#fff", tol: 4, blank: false},
+		{name: "noise within tolerance", img: noisy, bg: "#fff", tol: 5, blank: true},
+		{name: "transparent", img: transparent, bg: "transparent", blank: true},
+		{name: "white is not transparent", img: white, bg: "transparent", blank: false},
+		{name: "uniform", img: white, bg: "auto", blank: true},
+		{name: "not uniform", img: noisy, bg: "auto", blank: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			bg, err := ParseBackground(tc.bg, tc.tol)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, IsBlank(tc.img, bg), tc.blank)
+		})
+	}
+}
+
+func TestPackImages_SkipBlank(t *testing.T) {
+	// gradient with blank white top right corner, it is two tiles: 32x32 and 4x32 px
+	img := gradient()
+	draw.Draw(img, image.Rect(64, 0, 100, 32), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	images, err := CutImage(img, 32, 32)
+	assert.Equal(t, err, nil)
+
+	bg, err := ParseBackground("#ffffff", 0)
+	assert.Equal(t, err, nil)
+
+	buf := bytes.Buffer{}
+	zipWriter := zip.NewWriter(&buf)
+	manifest, err := PackImages(zipWriter, images, PackOptions{
+		Name: "pic", Format: FormatPNG, Source: SourceInfo{Width: 100, Height: 70}, SkipBlank: &bg,
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, zipWriter.Close(), nil)
+
+	assert.Equal(t, len(manifest.Tiles), 10)
+	assert.Equal(t, len(manifest.Skipped), 2)
+	assert.Equal(t, manifest.Skipped[0], SkippedTile{Row: 0, Col: 2, Index: 2, X: 64, Y: 0, Width: 32, Height: 32, Color: "#ffffffff"})
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(archive.File), 11)
+
+	stitched, err := Stitch(archive)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, color.NRGBAModel.Convert(stitched.At(80, 10)), color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+	assert.Equal(t, stitched.At(10, 10), img.At(10, 10))
+}
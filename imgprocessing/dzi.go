@@ -0,0 +1,144 @@
+package imgprocessing
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+)
+
+var ErrBadTileSize = errors.New("bad tile size or overlap")
+
+const (
+	DefaultDeepZoomTileSize = 254
+	DefaultDeepZoomOverlap  = 1
+	MaxTileSize             = 4096
+)
+
+// DeepZoomOptions configures PackDeepZoom.
+type DeepZoomOptions struct {
+	Name     string // base name of "<name>.dzi" and "<name>_files"
+	TileSize int
+	Overlap  int    // px shared by neighbour tiles
+	Format   Format // jpeg if empty
+}
+
+// Validate checks tile size against MinPieceSize of grid cutter: smaller tiles
+// multiply number of files in archive without any use for viewers.
+func (opts DeepZoomOptions) Validate() error {
+	if opts.TileSize < MinPieceSize || opts.TileSize > MaxTileSize || opts.Overlap < 0 || opts.Overlap > opts.TileSize/2 {
+		return fmt.Errorf("%w: tile %d px, overlap %d px", ErrBadTileSize, opts.TileSize, opts.Overlap)
+	}
+
+	return nil
+}
+
+type dziSize struct {
+	Width  int `xml:"Width,attr"`
+	Height int `xml:"Height,attr"`
+}
+
+type dziImage struct {
+	XMLName  xml.Name `xml:"http://schemas.microsoft.com/deepzoom/2008 Image"`
+	Format   string   `xml:"Format,attr"`
+	Overlap  int      `xml:"Overlap,attr"`
+	TileSize int      `xml:"TileSize,attr"`
+	Size     dziSize  `xml:"Size"`
+}
+
+// PackDeepZoom writes Deep Zoom Image pyramid: "<name>.dzi" descriptor and tiles
+// "<name>_files/<level>/<col>_<row>.<ext>". Level 0 is 1x1 px, the last level is img itself,
+// every level is twice smaller than the next one. Returns number of levels and tiles.
+func PackDeepZoom(dest *zip.Writer, img image.Image, opts DeepZoomOptions) (levels int, tiles int, err error) {
+	if opts.Format == "" {
+		opts.Format = FormatJPEG
+	}
+
+	if err := opts.Validate(); err != nil {
+		return 0, 0, err
+	}
+
+	b := img.Bounds()
+	if b.Empty() {
+		return 0, 0, ErrEmptyGrid
+	}
+
+	maxLevel := countHalvings(maxInt(b.Dx(), b.Dy()))
+	level := toNRGBA(img)
+
+	for l := maxLevel; l >= 0; l-- {
+		n, err := packDeepZoomLevel(dest, level, l, opts)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		tiles += n
+
+		if l > 0 {
+			level = halve(level)
+		}
+	}
+
+	w, err := dest.Create(opts.Name + ".dzi")
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable write zip archive: %w", err)
+	}
+
+	descriptor := dziImage{
+		Format:   opts.Format.Ext(),
+		Overlap:  opts.Overlap,
+		TileSize: opts.TileSize,
+		Size:     dziSize{Width: b.Dx(), Height: b.Dy()},
+	}
+
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		return 0, 0, fmt.Errorf("unable write zip archive: %w", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(descriptor); err != nil {
+		return 0, 0, fmt.Errorf("unable write zip archive: %w", err)
+	}
+
+	return maxLevel + 1, tiles, nil
+}
+
+func packDeepZoomLevel(dest *zip.Writer, level *image.NRGBA, l int, opts DeepZoomOptions) (int, error) {
+	w, h := level.Bounds().Dx(), level.Bounds().Dy()
+	n := 0
+
+	for row := 0; row*opts.TileSize < h; row++ {
+		for col := 0; col*opts.TileSize < w; col++ {
+			r := image.Rect(
+				col*opts.TileSize-opts.Overlap, row*opts.TileSize-opts.Overlap,
+				(col+1)*opts.TileSize+opts.Overlap, (row+1)*opts.TileSize+opts.Overlap,
+			).Intersect(level.Bounds())
+
+			f, err := dest.Create(fmt.Sprintf("%s_files/%d/%d_%d.%s", opts.Name, l, col, row, opts.Format.Ext()))
+			if err != nil {
+				return 0, fmt.Errorf("unable write zip archive: %w", err)
+			}
+
+			if err := Encode(f, level.SubImage(r), opts.Format); err != nil {
+				return 0, fmt.Errorf("unable write zip archive: %w", err)
+			}
+
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// countHalvings returns how many times size is halved (rounding up) to get 1: ceil(log2(size)).
+func countHalvings(size int) int {
+	n := 0
+	for ; size > 1; n++ {
+		size = (size + 1) / 2
+	}
+
+	return n
+}
@@ -0,0 +1,101 @@
+package imgprocessing
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestPackDeepZoom(t *testing.T) {
+	buf := bytes.Buffer{}
+	zipWriter := zip.NewWriter(&buf)
+	levels, tiles, err := PackDeepZoom(zipWriter, gradient(), DeepZoomOptions{Name: "pic", TileSize: 32, Overlap: 1, Format: FormatPNG})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, zipWriter.Close(), nil)
+
+	// 100x70, 50x35, 25x18, 13x9, 7x5, 4x3, 2x2, 1x1
+	assert.Equal(t, levels, 8)
+	assert.Equal(t, tiles, 4*3+2*2+6)
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(archive.File), tiles+1)
+
+	for name, size := range map[string]image.Point{
+		"pic_files/7/0_0.png": {33, 33},
+		"pic_files/7/1_1.png": {34, 34},
+		"pic_files/7/3_2.png": {5, 7},
+		"pic_files/6/1_1.png": {19, 4},
+		"pic_files/0/0_0.png": {1, 1},
+	} {
+		cfg, err := decodeZipConfig(archiveFile(t, archive, name))
+		assert.Equal(t, err, nil)
+		assert.Equal(t, image.Pt(cfg.Width, cfg.Height), size, name)
+	}
+
+	r, err := archiveFile(t, archive, "pic.dzi").Open()
+	assert.Equal(t, err, nil)
+	descriptor, err := io.ReadAll(r)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, strings.Contains(string(descriptor), `Format="png" Overlap="1" TileSize="32"`), true)
+	assert.Equal(t, strings.Contains(string(descriptor), `<Size Width="100" Height="70"></Size>`), true)
+
+	for _, opts := range []DeepZoomOptions{{TileSize: 0}, {TileSize: 32, Overlap: 17}, {TileSize: 32, Overlap: -1}} {
+		_, _, err = PackDeepZoom(zip.NewWriter(&bytes.Buffer{}), gradient(), opts)
+		assert.Equal(t, err != nil, true)
+	}
+}
+
+func TestDeepZoomOptions_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		opts    DeepZoomOptions
+		wantErr bool
+	}{
+		{name: "min tile", opts: DeepZoomOptions{TileSize: MinPieceSize}},
+		{name: "below min tile", opts: DeepZoomOptions{TileSize: MinPieceSize - 1}, wantErr: true},
+		{name: "one px tile", opts: DeepZoomOptions{TileSize: 1}, wantErr: true},
+		{name: "max tile", opts: DeepZoomOptions{TileSize: MaxTileSize}},
+		{name: "above max tile", opts: DeepZoomOptions{TileSize: MaxTileSize + 1}, wantErr: true},
+		{name: "max overlap", opts: DeepZoomOptions{TileSize: 64, Overlap: 32}},
+		{name: "above max overlap", opts: DeepZoomOptions{TileSize: 64, Overlap: 33}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.opts.Validate()
+			assert.Equal(t, errors.Is(err, ErrBadTileSize), tc.wantErr)
+		})
+	}
+}
+
+func TestHalve(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	src.Set(0, 0, color.NRGBA{R: 200, A: 255})
+	src.Set(1, 0, color.NRGBA{R: 0, G: 100, A: 0}) // transparent pixel does not darken neighbour
+	src.Set(2, 0, color.NRGBA{B: 50, A: 255})
+
+	dst := halve(src)
+	assert.Equal(t, dst.Bounds().Size(), image.Pt(2, 1))
+	assert.Equal(t, dst.NRGBAAt(0, 0), color.NRGBA{R: 200, A: 127})
+	assert.Equal(t, dst.NRGBAAt(1, 0), color.NRGBA{B: 50, A: 255})
+}
+
+func archiveFile(t *testing.T, archive *zip.Reader, name string) *zip.File {
+	for _, f := range archive.File {
+		if f.Name == name {
+			return f
+		}
+	}
+
+	t.Fatalf("no %s in archive", name)
+
+	return nil
+}
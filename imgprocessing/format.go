@@ -0,0 +1,50 @@
+package imgprocessing
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported output format")
+
+// Format is an output encoding of cut pieces.
+type Format string
+
+const (
+	FormatJPEG Format = "jpeg"
+	FormatPNG  Format = "png"
+)
+
+// ParseFormat accepts format names case-insensitively, "jpg" is an alias of "jpeg".
+// Empty string means default format (jpeg).
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "jpeg", "jpg":
+		return FormatJPEG, nil
+	case "png":
+		return FormatPNG, nil
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, s)
+}
+
+// Ext returns file extension of format without leading dot.
+func (f Format) Ext() string {
+	return string(f)
+}
+
+func Encode(w io.Writer, img image.Image, f Format) error {
+	switch f {
+	case FormatJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 100})
+	case FormatPNG:
+		return png.Encode(w, img)
+	}
+
+	return fmt.Errorf("%w: %q", ErrUnsupportedFormat, f)
+}
@@ -0,0 +1,110 @@
+package imgprocessing
+
+import (
+	"errors"
+	"fmt"
+	"image"
+)
+
+var ErrBadGuides = errors.New("bad guides")
+
+// Guides describe an irregular grid. Every axis is given either by cut positions
+// (X, Y) or by sizes of pieces (Columns, Rows), an empty axis is not cut at all.
+type Guides struct {
+	X       []int // x of vertical cut lines, increasing, inside image
+	Y       []int // y of horizontal cut lines, increasing, inside image
+	Columns []int // widths of columns from left, the rest of image becomes one more column
+	Rows    []int // heights of rows from top, the rest of image becomes one more row
+}
+
+// Validate checks guides without image, bounds are checked by CutGuides.
+func (g Guides) Validate() error {
+	if len(g.X)+len(g.Y)+len(g.Columns)+len(g.Rows) == 0 {
+		return fmt.Errorf("%w: no guides", ErrBadGuides)
+	}
+
+	if len(g.X) > 0 && len(g.Columns) > 0 {
+		return fmt.Errorf("%w: both x and column widths are set", ErrBadGuides)
+	}
+
+	if len(g.Y) > 0 && len(g.Rows) > 0 {
+		return fmt.Errorf("%w: both y and row heights are set", ErrBadGuides)
+	}
+
+	for _, sizes := range [][]int{g.Columns, g.Rows} {
+		for _, s := range sizes {
+			if s < 1 {
+				return fmt.Errorf("%w: size %d px", ErrBadGuides, s)
+			}
+		}
+	}
+
+	return nil
+}
+
+// edges returns positions of all piece edges along one axis of length size, from 0 to size.
+func edges(size int, cuts, sizes []int, axis string) ([]int, error) {
+	out := []int{0}
+
+	if len(sizes) > 0 {
+		cuts = make([]int, 0, len(sizes))
+		pos := 0
+
+		for _, s := range sizes {
+			pos += s
+			cuts = append(cuts, pos)
+		}
+
+		// размеры могут покрывать изображение целиком
+		if pos == size {
+			cuts = cuts[:len(cuts)-1]
+		}
+	}
+
+	for _, c := range cuts {
+		if c <= out[len(out)-1] || c >= size {
+			return nil, fmt.Errorf("%w: %s cut at %d px, image is %d px", ErrBadGuides, axis, c, size)
+		}
+
+		out = append(out, c)
+	}
+
+	return append(out, size), nil
+}
+
+// CutGuides cuts img into irregular grid images[row][col], pieces share pixels with img.
+func CutGuides(img image.Image, g Guides) ([][]image.Image, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+
+	b := img.Bounds()
+
+	xs, err := edges(b.Dx(), g.X, g.Columns, "x")
+	if err != nil {
+		return nil, err
+	}
+
+	ys, err := edges(b.Dy(), g.Y, g.Rows, "y")
+	if err != nil {
+		return nil, err
+	}
+
+	subImager, err := castSubImager(img)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([][]image.Image, len(ys)-1)
+
+	for row := range images {
+		images[row] = make([]image.Image, len(xs)-1)
+
+		for col := range images[row] {
+			r := image.Rect(xs[col], ys[row], xs[col+1], ys[row+1]).Add(b.Min)
+			images[row][col] = subImager.SubImage(r)
+		}
+	}
+
+	return images, nil
+}
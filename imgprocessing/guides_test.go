@@ -0,0 +1,52 @@
+package imgprocessing
+
+import (
+	"image"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestCutGuides(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 840, 300))
+
+	tests := []struct {
+		name   string
+		guides Guides
+		widths []int // of the first row
+		rows   int
+		err    bool
+	}{
+		{name: "columns", guides: Guides{Columns: []int{120, 600, 120}}, widths: []int{120, 600, 120}, rows: 1},
+		{name: "columns with rest", guides: Guides{Columns: []int{120, 600}}, widths: []int{120, 600, 120}, rows: 1},
+		{name: "x", guides: Guides{X: []int{120, 720}, Rows: []int{100}}, widths: []int{120, 600, 120}, rows: 2},
+		{name: "only y", guides: Guides{Y: []int{10, 20, 30}}, widths: []int{840}, rows: 4},
+		{name: "no guides", guides: Guides{}, err: true},
+		{name: "x and columns", guides: Guides{X: []int{10}, Columns: []int{10}}, err: true},
+		{name: "not increasing", guides: Guides{X: []int{720, 120}}, err: true},
+		{name: "on edge", guides: Guides{X: []int{0}}, err: true},
+		{name: "out of image", guides: Guides{X: []int{900}}, err: true},
+		{name: "columns too wide", guides: Guides{Columns: []int{800, 100}}, err: true},
+		{name: "zero size", guides: Guides{Rows: []int{0}}, err: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			images, err := CutGuides(img, tc.guides)
+			assert.Equal(t, err != nil, tc.err)
+
+			if tc.err {
+				return
+			}
+
+			assert.Equal(t, len(images), tc.rows)
+
+			widths := make([]int, len(images[0]))
+			for i, tile := range images[0] {
+				widths[i] = tile.Bounds().Dx()
+			}
+			assert.Equal(t, widths, tc.widths)
+			assert.Equal(t, GridBounds(images), img.Bounds())
+		})
+	}
+}
@@ -2,11 +2,13 @@ package imgprocessing
 
 import (
 	"archive/zip"
+	"bytes"
 	"errors"
 	"fmt"
 	"image"
-	"image/jpeg"
-	_ "image/png" // registers img formats
+	_ "image/jpeg" // registers img formats
+	_ "image/png"
+	"io"
 	"log"
 	"os"
 )
@@ -16,6 +18,9 @@ var (
 	ErrSmallCut      = errors.New("cut too small")
 )
 
+// MinPieceSize is the smallest allowed width and height of a piece in px.
+const MinPieceSize = 32
+
 type subImager interface {
 	image.Image
 	SubImage(r image.Rectangle) image.Image
@@ -121,7 +126,7 @@ func castSubImager(img image.Image) (subImager, error) {
 
 // note: every unit of [][]image.Image shares pixels with img
 func CutImage(img image.Image, pieceWidth int, pieceHeigth int) ([][]image.Image, error) {
-	if pieceWidth < 32 || pieceHeigth < 32 {
+	if pieceWidth < MinPieceSize || pieceHeigth < MinPieceSize {
 		return nil, ErrSmallCut
 	}
 
@@ -152,38 +157,124 @@ func CutImage(img image.Image, pieceWidth int, pieceHeigth int) ([][]image.Image
 	return images, nil
 }
 
-func PackImages(dest *zip.Writer, images [][]image.Image, namePrefix string) error {
-	if namePrefix != "" {
-		namePrefix += "_"
+// PackOptions configures naming and encoding of tiles in archive.
+type PackOptions struct {
+	Name   string        // source name without extension, used by {name}
+	Format Format        // empty means jpeg
+	Naming *NameTemplate // nil means DefaultNameTemplate
+	Labels []string      // {label} of tiles counting row by row
+
+	Source      SourceInfo // copied to manifest
+	Params      any        // copied to manifest
+	ManifestCSV bool       // write manifest.csv next to manifest.json
+
+	Transform *Transform  // copied to manifest
+	Canvas    image.Point // size of image tiles are placed on, zero means size of Source
+	SkipBlank *Background // tiles made only of this background are left out, nil keeps all
+	Dedup     bool        // byte-identical tiles are stored once, see ManifestTile.Duplicate
+}
+
+// PackImages encodes every tile of grid into dest followed by manifest.json.
+// Names are checked before anything is written, so on ErrNameCollision dest stays untouched.
+// Blank tiles are listed in manifest as skipped if opts.SkipBlank is set, other tiles keep their names.
+func PackImages(dest *zip.Writer, images [][]image.Image, opts PackOptions) (*Manifest, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := Layout(images, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := Manifest{
+		Version: manifestVersion,
+		Source:  opts.Source,
+		Params:  opts.Params,
+		Format:  opts.Format,
+		Tiles:   make([]ManifestTile, 0, len(entries)),
+
+		Transform: opts.Transform,
 	}
 
-	digitsByX := countDigits(len(images))
-	digitsByY := countDigits(len(images[0]))
+	if c := opts.Canvas; c != (image.Point{}) && c != image.Pt(opts.Source.Width, opts.Source.Height) {
+		manifest.Canvas = &CanvasSize{Width: c.X, Height: c.Y}
+	}
+
+	stored := map[string]string{} // sha256 -> file, used by Dedup
+	buf := bytes.Buffer{}
 
-	// "%s%0Xdx%0Yd.jpeg", digitsByX = 2, digitsByY = 4 -> "%s%02dx%04d.jpeg"
-	fileNameTemplate := fmt.Sprintf("%%s%%0%ddx%%0%dd.jpeg", digitsByX, digitsByY)
-	options := jpeg.Options{Quality: 100}
+	for _, e := range entries {
+		tile := images[e.Row][e.Col]
+		if opts.SkipBlank != nil && IsBlank(tile, *opts.SkipBlank) {
+			manifest.Skipped = append(manifest.Skipped, skippedTile(e, tile))
+			continue
+		}
 
-	for x, sliceByX := range images {
-		for y, image := range sliceByX {
-			w, err := dest.Create(fmt.Sprintf(fileNameTemplate, namePrefix, x+1, y+1))
+		if !opts.Dedup {
+			w, err := dest.Create(e.File)
 			if err != nil {
-				return fmt.Errorf("unable write zip archive: %w", err)
+				return nil, fmt.Errorf("unable write zip archive: %w", err)
 			}
 
-			if err := jpeg.Encode(w, image, &options); err != nil {
-				return fmt.Errorf("unable write zip archive: %w", err)
+			hw := newHashingWriter()
+			if err := Encode(io.MultiWriter(w, hw), tile, opts.Format); err != nil {
+				return nil, fmt.Errorf("unable write zip archive: %w", err)
 			}
+
+			manifest.Tiles = append(manifest.Tiles, hw.tile(e))
+
+			continue
+		}
+
+		// хэш известен только после кодирования, поэтому кусок сначала кодируется в память
+		buf.Reset()
+		hw := newHashingWriter()
+		if err := Encode(io.MultiWriter(&buf, hw), tile, opts.Format); err != nil {
+			return nil, fmt.Errorf("unable encode tile: %w", err)
+		}
+
+		t := hw.tile(e)
+		if manifest.Dedup == nil {
+			manifest.Dedup = &DedupStats{}
+		}
+
+		if file, ok := stored[t.SHA256]; ok {
+			t.File, t.Duplicate = file, true
+			manifest.Dedup.Duplicates++
+			manifest.Dedup.BytesSaved += t.Bytes
+			manifest.Tiles = append(manifest.Tiles, t)
+
+			continue
 		}
+
+		w, err := dest.Create(e.File)
+		if err != nil {
+			return nil, fmt.Errorf("unable write zip archive: %w", err)
+		}
+
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return nil, fmt.Errorf("unable write zip archive: %w", err)
+		}
+
+		stored[t.SHA256] = e.File
+		manifest.Dedup.Unique++
+		manifest.Tiles = append(manifest.Tiles, t)
+	}
+
+	if err := writeManifest(dest, &manifest, opts.ManifestCSV); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &manifest, nil
 }
 
+// countDigits returns number of decimal digits of non-negative i, zero has one digit.
 func countDigits(i int) int {
-	count := 0
+	count := 1
 
-	for i > 0 {
+	for i >= 10 {
 		i /= 10
 		count++
 	}
@@ -0,0 +1,142 @@
+package imgprocessing
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestPackImages_Manifest(t *testing.T) {
+	images := makeGrid(t, 2, 3)
+	buf := bytes.Buffer{}
+	zipWriter := zip.NewWriter(&buf)
+
+	manifest, err := PackImages(zipWriter, images, PackOptions{
+		Name:        "pic",
+		Format:      FormatPNG,
+		Source:      SourceInfo{Name: "pic.png", Width: 96, Height: 64, Format: "png"},
+		Params:      map[string]int{"dX": 32, "dY": 32},
+		ManifestCSV: true,
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, zipWriter.Close(), nil)
+	assert.Equal(t, len(manifest.Tiles), 6)
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(archive.File), 6+2)
+
+	files := map[string][]byte{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		assert.Equal(t, err, nil)
+		b, err := io.ReadAll(r)
+		assert.Equal(t, err, nil)
+		files[f.Name] = b
+	}
+
+	var written Manifest
+	assert.Equal(t, json.Unmarshal(files[ManifestFile], &written), nil)
+	assert.Equal(t, written.Source.Width, 96)
+	assert.Equal(t, written.Format, FormatPNG)
+	assert.Equal(t, len(written.Tiles), 6)
+
+	for _, tile := range written.Tiles {
+		sum := sha256.Sum256(files[tile.File])
+		assert.Equal(t, tile.SHA256, hex.EncodeToString(sum[:]))
+		assert.Equal(t, tile.Bytes, int64(len(files[tile.File])))
+		assert.Equal(t, tile.X, tile.Col*32)
+		assert.Equal(t, tile.Y, tile.Row*32)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(files[ManifestCSVFile])).ReadAll()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(records), 6+1)
+	assert.Equal(t, records[6][0], "pic_2x3.png")
+}
+
+func TestManifestCSV(t *testing.T) {
+	m := &Manifest{
+		Tiles: []ManifestTile{
+			{TileEntry: TileEntry{File: "logo.png", Index: 0, Label: "logo"}, Width: 32, Height: 32, Bytes: 10, SHA256: "aa"},
+			{TileEntry: TileEntry{File: "logo.png", Col: 2, Index: 2, Label: "copy"}, X: 64, Width: 32, Height: 32, Bytes: 10, SHA256: "aa", Duplicate: true},
+		},
+		Skipped: []SkippedTile{{Col: 1, Index: 1, X: 32, Width: 32, Height: 32, Color: "#ffffffff"}},
+	}
+
+	buf := bytes.Buffer{}
+	zipWriter := zip.NewWriter(&buf)
+	assert.Equal(t, writeManifest(zipWriter, m, true), nil)
+	assert.Equal(t, zipWriter.Close(), nil)
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Equal(t, err, nil)
+
+	r, err := archiveFile(t, archive, ManifestCSVFile).Open()
+	assert.Equal(t, err, nil)
+	records, err := csv.NewReader(r).ReadAll()
+	assert.Equal(t, err, nil)
+
+	// пропущенные и повторяющиеся куски и подписи есть и в csv, строки по порядку кусков
+	assert.Equal(t, records, [][]string{
+		{"file", "row", "col", "index", "label", "x", "y", "width", "height", "bytes", "sha256", "status", "color"},
+		{"logo.png", "0", "0", "0", "logo", "0", "0", "32", "32", "10", "aa", "stored", ""},
+		{"", "0", "1", "1", "", "32", "0", "32", "32", "0", "", "skipped", "#ffffffff"},
+		{"logo.png", "0", "2", "2", "copy", "64", "0", "32", "32", "10", "aa", "duplicate", ""},
+	})
+}
+
+func TestPackImages_Dedup(t *testing.T) {
+	// blank tiles are all the same, gradient tiles are all different
+	images := makeGrid(t, 2, 3)
+	gradientTiles, err := CutImage(gradient(), 32, 32)
+	assert.Equal(t, err, nil)
+	images = append(images, gradientTiles[0])
+
+	buf := bytes.Buffer{}
+	zipWriter := zip.NewWriter(&buf)
+	manifest, err := PackImages(zipWriter, images, PackOptions{Name: "pic", Format: FormatPNG, Dedup: true})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, zipWriter.Close(), nil)
+
+	assert.Equal(t, len(manifest.Tiles), 6+4)
+	assert.Equal(t, manifest.Dedup.Unique, 1+4)
+	assert.Equal(t, manifest.Dedup.Duplicates, 5)
+	assert.Equal(t, manifest.Dedup.BytesSaved, 5*manifest.Tiles[0].Bytes)
+
+	for _, tile := range manifest.Tiles[1:6] {
+		assert.Equal(t, tile.File, "pic_1x1.png")
+		assert.Equal(t, tile.Duplicate, true)
+	}
+
+	assert.Equal(t, manifest.Tiles[7].File, "pic_3x2.png")
+	assert.Equal(t, manifest.Tiles[7].Duplicate, false)
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(archive.File), 5+1)
+}
+
+func TestStitch_Dedup(t *testing.T) {
+	buf := bytes.Buffer{}
+	zipWriter := zip.NewWriter(&buf)
+	_, err := PackImages(zipWriter, makeGrid(t, 2, 3), PackOptions{
+		Name: "pic", Format: FormatPNG, Source: SourceInfo{Width: 96, Height: 64}, Dedup: true,
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, zipWriter.Close(), nil)
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Equal(t, err, nil)
+
+	stitched, err := Stitch(archive)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, stitched.Bounds().Size().X, 96)
+}
@@ -0,0 +1,97 @@
+package imgprocessing
+
+import (
+	"errors"
+	"image"
+)
+
+var ErrEmptyGrid = errors.New("no tiles to pack")
+
+// TileEntry tells where a tile of the grid is stored in archive.
+type TileEntry struct {
+	File   string          `json:"file"`
+	Row    int             `json:"row"`   // 0-based, images[Row][Col] is the tile
+	Col    int             `json:"col"`   // 0-based
+	Index  int             `json:"index"` // 0-based, counting row by row
+	Label  string          `json:"label,omitempty"`
+	Bounds image.Rectangle `json:"-"` // pixel rectangle of tile in source image
+}
+
+// withDefaults sets jpeg format and DefaultNameTemplate if they are not set.
+func (opts PackOptions) withDefaults() (PackOptions, error) {
+	if opts.Format == "" {
+		opts.Format = FormatJPEG
+	}
+
+	if opts.Naming == nil {
+		naming, err := ParseNameTemplate(DefaultNameTemplate)
+		if err != nil {
+			return opts, err
+		}
+		opts.Naming = naming
+	}
+
+	return opts, nil
+}
+
+// Layout names every tile of grid images[row][col]; rows may differ in length.
+// Numeric template variables are padded by the largest value over the whole grid,
+// so names sort in the same order as tiles. Returns ErrEmptyGrid if there are no tiles,
+// ErrNameCollision or ErrBadNameTemplate if names are not unique or unsafe.
+func Layout(images [][]image.Image, opts PackOptions) ([]TileEntry, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make([]TileVars, 0)
+	bounds := make([]image.Rectangle, 0)
+
+	for row, sliceByRow := range images {
+		for col, tile := range sliceByRow {
+			b := tile.Bounds()
+			v := TileVars{
+				Row: row, Col: col, Index: len(vars),
+				X: b.Min.X, Y: b.Min.Y, Width: b.Dx(), Height: b.Dy(),
+				Name: opts.Name, Ext: opts.Format.Ext(),
+			}
+			if v.Index < len(opts.Labels) {
+				v.Label = opts.Labels[v.Index]
+			}
+
+			vars = append(vars, v)
+			bounds = append(bounds, b)
+		}
+	}
+
+	if len(vars) == 0 {
+		return nil, ErrEmptyGrid
+	}
+
+	maxVars := maxTileVars(vars)
+	entries := make([]TileEntry, len(vars))
+	names := make([]string, len(vars))
+
+	for i, v := range vars {
+		names[i] = opts.Naming.Execute(v, maxVars)
+		entries[i] = TileEntry{File: names[i], Row: v.Row, Col: v.Col, Index: v.Index, Label: v.Label, Bounds: bounds[i]}
+	}
+
+	if err := checkNames(names); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GridBounds returns union of bounds of all tiles.
+func GridBounds(images [][]image.Image) image.Rectangle {
+	r := image.Rectangle{}
+	for _, row := range images {
+		for _, tile := range row {
+			r = r.Union(tile.Bounds())
+		}
+	}
+
+	return r
+}
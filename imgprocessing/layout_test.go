@@ -0,0 +1,169 @@
+package imgprocessing
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image"
+	"math/rand"
+	"sort"
+	"testing"
+	"testing/quick"
+
+	"github.com/magiconair/properties/assert"
+)
+
+// makeGrid cuts blank image of rows x cols pieces 32x32 px.
+func makeGrid(t *testing.T, rows int, cols int) [][]image.Image {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, cols*MinPieceSize, rows*MinPieceSize))
+
+	images, err := CutImage(img, MinPieceSize, MinPieceSize)
+	assert.Equal(t, err, nil)
+
+	return images
+}
+
+func TestLayout(t *testing.T) {
+	testCases := []struct {
+		name      string
+		rows      int
+		cols      int
+		template  string
+		wantFiles []string // first and last file names
+		wantErr   error
+	}{
+		{
+			name:      "1x1",
+			rows:      1,
+			cols:      1,
+			wantFiles: []string{"pic_1x1.jpeg", "pic_1x1.jpeg"},
+		},
+		{
+			name:      "1xN",
+			rows:      1,
+			cols:      12,
+			wantFiles: []string{"pic_1x01.jpeg", "pic_1x12.jpeg"},
+		},
+		{
+			name:      "Nx1",
+			rows:      12,
+			cols:      1,
+			wantFiles: []string{"pic_01x1.jpeg", "pic_12x1.jpeg"},
+		},
+		{
+			name:      "10x100",
+			rows:      10,
+			cols:      100,
+			wantFiles: []string{"pic_01x001.jpeg", "pic_10x100.jpeg"},
+		},
+		{
+			name:      "zero based",
+			rows:      10,
+			cols:      3,
+			template:  "tile_{col0}_{row0}.{ext}",
+			wantFiles: []string{"tile_0_0.jpeg", "tile_2_9.jpeg"},
+		},
+		{
+			name:      "directories",
+			rows:      2,
+			cols:      11,
+			template:  "{row:1}/{col:1}.png",
+			wantFiles: []string{"1/1.png", "2/11.png"},
+		},
+		{
+			name:      "index and offsets",
+			rows:      4,
+			cols:      4,
+			template:  "{index}_{x}_{y}_{width}x{height}.{ext}",
+			wantFiles: []string{"01_00_00_32x32.jpeg", "16_96_96_32x32.jpeg"},
+		},
+		{
+			name:    "empty grid",
+			rows:    0,
+			cols:    0,
+			wantErr: ErrEmptyGrid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var images [][]image.Image
+			if tc.rows > 0 {
+				images = makeGrid(t, tc.rows, tc.cols)
+			}
+
+			naming, err := ParseNameTemplate(tc.template)
+			assert.Equal(t, err, nil)
+
+			entries, err := Layout(images, PackOptions{Name: "pic", Naming: naming})
+			assert.Equal(t, err, tc.wantErr)
+
+			if tc.wantErr != nil {
+				return
+			}
+
+			assert.Equal(t, len(entries), tc.rows*tc.cols)
+			assert.Equal(t, entries[0].File, tc.wantFiles[0])
+			assert.Equal(t, entries[len(entries)-1].File, tc.wantFiles[1])
+			assert.Equal(t, entries[len(entries)-1].Row, tc.rows-1)
+			assert.Equal(t, entries[len(entries)-1].Col, tc.cols-1)
+		})
+	}
+}
+
+func TestLayout_EmptyRows(t *testing.T) {
+	_, err := Layout([][]image.Image{{}, {}}, PackOptions{})
+	assert.Equal(t, err, ErrEmptyGrid)
+
+	_, err = PackImages(zip.NewWriter(&bytes.Buffer{}), nil, PackOptions{})
+	assert.Equal(t, err, ErrEmptyGrid)
+}
+
+// TestLayout_Properties checks that for any grid default names are unique,
+// sort in the same order as tiles and every entry points to the tile it was built from.
+func TestLayout_Properties(t *testing.T) {
+	property := func(r, c uint8) bool {
+		rows, cols := int(r)%40+1, int(c)%40+1
+		images := makeGrid(t, rows, cols)
+
+		entries, err := Layout(images, PackOptions{Name: "pic"})
+		if err != nil || len(entries) != rows*cols {
+			return false
+		}
+
+		names := make([]string, len(entries))
+		for i, e := range entries {
+			names[i] = e.File
+
+			if e.Index != i || e.Index != e.Row*cols+e.Col || e.Bounds != images[e.Row][e.Col].Bounds() {
+				return false
+			}
+
+			var row, col int
+			if _, err := fmt.Sscanf(e.File, "pic_%dx%d.jpeg", &row, &col); err != nil || row != e.Row+1 || col != e.Col+1 {
+				return false
+			}
+		}
+
+		return sort.StringsAreSorted(names)
+	}
+
+	config := quick.Config{MaxCount: 50, Rand: rand.New(rand.NewSource(1))}
+	if err := quick.Check(property, &config); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLayout_UnsafeNames(t *testing.T) {
+	images := makeGrid(t, 2, 2)
+
+	for _, template := range []string{"/{row}x{col}", "../{row}x{col}", "{row}//{col}", "{row}/./{col}"} {
+		naming, err := ParseNameTemplate(template)
+		assert.Equal(t, err, nil)
+
+		_, err = Layout(images, PackOptions{Naming: naming})
+		assert.Equal(t, err != nil, true)
+	}
+}
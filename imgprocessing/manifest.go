@@ -0,0 +1,180 @@
+package imgprocessing
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"sort"
+	"strconv"
+)
+
+const (
+	ManifestFile    = "manifest.json"
+	ManifestCSVFile = "manifest.csv"
+	manifestVersion = 1
+)
+
+// SourceInfo describes image tiles were cut from.
+type SourceInfo struct {
+	Name   string `json:"name"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Format string `json:"format"` // decoded format, "jpeg" or "png"
+}
+
+// CanvasSize is size of image tiles are placed on.
+type CanvasSize struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// ManifestTile describes a single file of archive.
+type ManifestTile struct {
+	TileEntry
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+
+	Duplicate bool `json:"duplicate,omitempty"` // File is stored for an earlier tile with the same content
+}
+
+// DedupStats tells how much PackImages saved by storing identical tiles once.
+type DedupStats struct {
+	Unique     int   `json:"unique"`     // tiles stored in archive
+	Duplicates int   `json:"duplicates"` // tiles mapped to a stored one
+	BytesSaved int64 `json:"bytesSaved"` // encoded size of duplicates
+}
+
+// Manifest is written into every archive as manifest.json, so tiles
+// can be reassembled or validated without parsing file names.
+type Manifest struct {
+	Version int            `json:"version"`
+	Source  SourceInfo     `json:"source"`
+	Params  any            `json:"params,omitempty"` // parameters of cut as passed by caller
+	Format  Format         `json:"format"`           // format of tiles
+	Tiles   []ManifestTile `json:"tiles"`
+
+	Transform *Transform  `json:"transform,omitempty"` // applied to source before cut
+	Canvas    *CanvasSize `json:"canvas,omitempty"`    // set if tiles cover other size than source
+
+	Skipped []SkippedTile `json:"skipped,omitempty"` // blank tiles not written to archive
+	Dedup   *DedupStats   `json:"dedup,omitempty"`   // set if identical tiles are stored once
+}
+
+// hashingWriter counts and hashes bytes written to archive.
+type hashingWriter struct {
+	hash  hash.Hash
+	bytes int64
+}
+
+func newHashingWriter() *hashingWriter {
+	return &hashingWriter{hash: sha256.New()}
+}
+
+func (hw *hashingWriter) Write(p []byte) (int, error) {
+	hw.bytes += int64(len(p))
+	return hw.hash.Write(p)
+}
+
+func (hw *hashingWriter) tile(e TileEntry) ManifestTile {
+	return ManifestTile{
+		TileEntry: e,
+		X:         e.Bounds.Min.X,
+		Y:         e.Bounds.Min.Y,
+		Width:     e.Bounds.Dx(),
+		Height:    e.Bounds.Dy(),
+		Bytes:     hw.bytes,
+		SHA256:    hex.EncodeToString(hw.hash.Sum(nil)),
+	}
+}
+
+func writeManifest(dest *zip.Writer, m *Manifest, withCSV bool) error {
+	w, err := dest.Create(ManifestFile)
+	if err != nil {
+		return fmt.Errorf("unable write manifest: %w", err)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+
+	if err := enc.Encode(m); err != nil {
+		return fmt.Errorf("unable write manifest: %w", err)
+	}
+
+	if !withCSV {
+		return nil
+	}
+
+	w, err = dest.Create(ManifestCSVFile)
+	if err != nil {
+		return fmt.Errorf("unable write manifest: %w", err)
+	}
+
+	if err := csv.NewWriter(w).WriteAll(manifestCSV(m)); err != nil {
+		return fmt.Errorf("unable write manifest: %w", err)
+	}
+
+	return nil
+}
+
+// Statuses of tile in manifest.csv.
+const (
+	csvStored    = "stored"
+	csvDuplicate = "duplicate" // file holds an earlier tile with the same content
+	csvSkipped   = "skipped"   // blank, not written, color is in "color"
+)
+
+// manifestCSV makes a row for every tile of m, written or skipped, in order of index.
+// Rows carry the same fields as Tiles and Skipped of manifest.json, fields of
+// the whole archive (source, params, dedup stats) are only in json.
+func manifestCSV(m *Manifest) [][]string {
+	header := []string{"file", "row", "col", "index", "label", "x", "y", "width", "height", "bytes", "sha256", "status", "color"}
+	rows := make([][]string, 0, len(m.Tiles)+len(m.Skipped))
+	index := make([]int, 0, cap(rows))
+
+	for _, t := range m.Tiles {
+		status := csvStored
+		if t.Duplicate {
+			status = csvDuplicate
+		}
+
+		rows = append(rows, []string{
+			t.File, strconv.Itoa(t.Row), strconv.Itoa(t.Col), strconv.Itoa(t.Index), t.Label,
+			strconv.Itoa(t.X), strconv.Itoa(t.Y), strconv.Itoa(t.Width), strconv.Itoa(t.Height),
+			strconv.FormatInt(t.Bytes, 10), t.SHA256, status, "",
+		})
+		index = append(index, t.Index)
+	}
+
+	for _, t := range m.Skipped {
+		rows = append(rows, []string{
+			"", strconv.Itoa(t.Row), strconv.Itoa(t.Col), strconv.Itoa(t.Index), "",
+			strconv.Itoa(t.X), strconv.Itoa(t.Y), strconv.Itoa(t.Width), strconv.Itoa(t.Height),
+			"0", "", csvSkipped, t.Color,
+		})
+		index = append(index, t.Index)
+	}
+
+	order := make([]int, len(rows))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool { return index[order[i]] < index[order[j]] })
+
+	out := make([][]string, 0, len(rows)+1)
+	out = append(out, header)
+
+	for _, i := range order {
+		out = append(out, rows[i])
+	}
+
+	return out
+}
@@ -0,0 +1,83 @@
+package imgprocessing
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// DefaultThumbnailSize is the longer side of thumbnail in px.
+const DefaultThumbnailSize = 128
+
+// ImageInfo describes decoded image.
+type ImageInfo struct {
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	Format     string `json:"format"`     // name of decoder: "jpeg", "png"
+	ColorModel string `json:"colorModel"` // human readable, like "YCbCr 4:2:0"
+}
+
+// Describe returns info of img decoded from format.
+func Describe(img image.Image, format string) ImageInfo {
+	return ImageInfo{
+		Width:      img.Bounds().Dx(),
+		Height:     img.Bounds().Dy(),
+		Format:     format,
+		ColorModel: ColorModelName(img),
+	}
+}
+
+var subsampleRatios = map[image.YCbCrSubsampleRatio]string{
+	image.YCbCrSubsampleRatio444: "4:4:4",
+	image.YCbCrSubsampleRatio422: "4:2:2",
+	image.YCbCrSubsampleRatio420: "4:2:0",
+	image.YCbCrSubsampleRatio440: "4:4:0",
+	image.YCbCrSubsampleRatio411: "4:1:1",
+	image.YCbCrSubsampleRatio410: "4:1:0",
+}
+
+// ColorModelName names colour model of img the way decoders store it.
+func ColorModelName(img image.Image) string {
+	switch img := img.(type) {
+	case *image.YCbCr:
+		return "YCbCr " + subsampleRatios[img.SubsampleRatio]
+	case *image.NYCbCrA:
+		return "YCbCrA " + subsampleRatios[img.SubsampleRatio]
+	case *image.Gray:
+		return "Gray"
+	case *image.Gray16:
+		return "Gray 16-bit"
+	case *image.RGBA:
+		return "RGBA"
+	case *image.RGBA64:
+		return "RGBA 16-bit"
+	case *image.NRGBA:
+		return "NRGBA"
+	case *image.NRGBA64:
+		return "NRGBA 16-bit"
+	case *image.CMYK:
+		return "CMYK"
+	case *image.Paletted:
+		return fmt.Sprintf("Paletted, %d colours", len(img.Palette))
+	case *image.Alpha, *image.Alpha16:
+		return "Alpha"
+	default:
+		return fmt.Sprintf("%T", img)
+	}
+}
+
+// Thumbnail fits img into size x size px keeping aspect ratio, small images are not enlarged.
+func Thumbnail(img image.Image, size int) *image.NRGBA {
+	if size <= 0 {
+		size = DefaultThumbnailSize
+	}
+
+	b := img.Bounds()
+	scale := math.Min(1, float64(size)/float64(maxInt(b.Dx(), b.Dy())))
+
+	if scale == 1 {
+		return toNRGBA(img)
+	}
+
+	return Resize(img, scaled(b.Size(), scale), FilterCatmullRom)
+}
@@ -0,0 +1,34 @@
+package imgprocessing
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		name  string
+		img   image.Image
+		model string
+	}{
+		{name: "jpeg", img: image.NewYCbCr(image.Rect(0, 0, 30, 20), image.YCbCrSubsampleRatio420), model: "YCbCr 4:2:0"},
+		{name: "gray", img: image.NewGray(image.Rect(0, 0, 30, 20)), model: "Gray"},
+		{name: "nrgba", img: image.NewNRGBA(image.Rect(0, 0, 30, 20)), model: "NRGBA"},
+		{name: "paletted", img: image.NewPaletted(image.Rect(0, 0, 30, 20), color.Palette{color.Black, color.White}), model: "Paletted, 2 colours"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, Describe(tc.img, "png"), ImageInfo{Width: 30, Height: 20, Format: "png", ColorModel: tc.model})
+		})
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	assert.Equal(t, Thumbnail(gradient(), 16).Bounds(), image.Rect(0, 0, 16, 11))
+	// small images are not enlarged
+	assert.Equal(t, Thumbnail(image.NewGray(image.Rect(5, 5, 15, 10)), 16).Bounds(), image.Rect(0, 0, 10, 5))
+}
@@ -0,0 +1,252 @@
+package imgprocessing
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrBadNameTemplate = errors.New("bad name template")
+	ErrNameCollision   = errors.New("tile names collide")
+)
+
+// DefaultNameTemplate produces names like "Picture_03x14.jpeg".
+const DefaultNameTemplate = "{name}_{row}x{col}.{ext}"
+
+// Template variables. Numeric ones are zero-padded to the width of the largest
+// value in the grid, "{row:N}" pads to N digits instead ("{row:1}" disables padding).
+//
+//	{row}, {col}       1-based position of tile
+//	{row0}, {col0}     0-based position of tile
+//	{index}, {index0}  position of tile counting row by row, 1-based and 0-based
+//	{x}, {y}           pixel offset of tile's top left corner
+//	{width}, {height}  tile size in px
+//	{name}             source file name without extension
+//	{ext}              extension of output format
+//	{label}            name of region, see PackOptions.Labels
+var templateVars = map[string]bool{ // value: is numeric
+	"row": true, "col": true, "row0": true, "col0": true,
+	"index": true, "index0": true,
+	"x": true, "y": true, "width": true, "height": true,
+	"name": false, "ext": false, "label": false,
+}
+
+// TileVars holds values substituted into NameTemplate for a single tile.
+type TileVars struct {
+	Row, Col int // 0-based
+	Index    int // 0-based
+	X, Y     int
+	Width    int
+	Height   int
+	Name     string
+	Ext      string
+	Label    string
+}
+
+func (v TileVars) number(name string) int {
+	switch name {
+	case "row":
+		return v.Row + 1
+	case "col":
+		return v.Col + 1
+	case "row0":
+		return v.Row
+	case "col0":
+		return v.Col
+	case "index":
+		return v.Index + 1
+	case "index0":
+		return v.Index
+	case "x":
+		return v.X
+	case "y":
+		return v.Y
+	case "width":
+		return v.Width
+	case "height":
+		return v.Height
+	}
+
+	return 0
+}
+
+type namePart struct {
+	literal  string
+	variable string
+	width    int // 0 means auto padding
+}
+
+// NameTemplate builds file names of tiles inside archive.
+type NameTemplate struct {
+	raw   string
+	parts []namePart
+}
+
+// ParseNameTemplate checks syntax of template and that it tells tiles apart:
+// it must contain {index}, both row and column, both {x} and {y}, or {label}.
+// Empty string means DefaultNameTemplate.
+func ParseNameTemplate(s string) (*NameTemplate, error) {
+	if s == "" {
+		s = DefaultNameTemplate
+	}
+
+	t := &NameTemplate{raw: s}
+	used := map[string]bool{}
+	rest := s
+
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			t.parts = append(t.parts, namePart{literal: rest})
+			break
+		}
+
+		if open > 0 {
+			t.parts = append(t.parts, namePart{literal: rest[:open]})
+		}
+
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("%w: unclosed brace in %q", ErrBadNameTemplate, s)
+		}
+
+		part, err := parseVariable(rest[open+1 : open+end])
+		if err != nil {
+			return nil, err
+		}
+
+		used[part.variable] = true
+		t.parts = append(t.parts, part)
+		rest = rest[open+end+1:]
+	}
+
+	if strings.ContainsAny(t.literals(), "}\\") {
+		return nil, fmt.Errorf("%w: unexpected character in %q", ErrBadNameTemplate, s)
+	}
+
+	distinct := used["index"] || used["index0"] ||
+		(used["row"] || used["row0"]) && (used["col"] || used["col0"]) ||
+		used["x"] && used["y"] || used["label"]
+	if !distinct {
+		return nil, fmt.Errorf("%w: %q gives every tile the same name", ErrNameCollision, s)
+	}
+
+	return t, nil
+}
+
+func parseVariable(s string) (namePart, error) {
+	name, width, hasWidth := strings.Cut(s, ":")
+
+	numeric, ok := templateVars[name]
+	if !ok {
+		return namePart{}, fmt.Errorf("%w: unknown variable {%s}", ErrBadNameTemplate, name)
+	}
+
+	part := namePart{variable: name}
+
+	if hasWidth {
+		w, err := strconv.Atoi(width)
+		if !numeric || err != nil || w < 1 || w > 9 {
+			return namePart{}, fmt.Errorf("%w: bad padding in {%s}", ErrBadNameTemplate, s)
+		}
+		part.width = w
+	}
+
+	return part, nil
+}
+
+func (t *NameTemplate) literals() string {
+	b := strings.Builder{}
+	for _, p := range t.parts {
+		b.WriteString(p.literal)
+	}
+
+	return b.String()
+}
+
+// String returns source of template.
+func (t *NameTemplate) String() string {
+	return t.raw
+}
+
+// Execute builds name of a single tile, maxVars holds largest values of
+// numeric variables and is used for auto padding.
+func (t *NameTemplate) Execute(v TileVars, maxVars TileVars) string {
+	b := strings.Builder{}
+
+	for _, p := range t.parts {
+		switch {
+		case p.variable == "":
+			b.WriteString(p.literal)
+		case p.variable == "name":
+			b.WriteString(v.Name)
+		case p.variable == "ext":
+			b.WriteString(v.Ext)
+		case p.variable == "label":
+			b.WriteString(v.Label)
+		default:
+			width := p.width
+			if width == 0 {
+				width = countDigits(maxVars.number(p.variable))
+			}
+			fmt.Fprintf(&b, "%0*d", width, v.number(p.variable))
+		}
+	}
+
+	return b.String()
+}
+
+// maxTileVars returns largest value of every numeric variable.
+func maxTileVars(vars []TileVars) TileVars {
+	m := TileVars{}
+
+	for _, v := range vars {
+		m.Row = maxInt(m.Row, v.Row)
+		m.Col = maxInt(m.Col, v.Col)
+		m.Index = maxInt(m.Index, v.Index)
+		m.X = maxInt(m.X, v.X)
+		m.Y = maxInt(m.Y, v.Y)
+		m.Width = maxInt(m.Width, v.Width)
+		m.Height = maxInt(m.Height, v.Height)
+	}
+
+	return m
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// checkNames rejects unsafe names, duplicates and names of manifest files,
+// the last are possible only with {label}.
+func checkNames(names []string) error {
+	seen := map[string]bool{ManifestFile: true, ManifestCSVFile: true}
+
+	for _, n := range names {
+		if n == "" || path.IsAbs(n) || path.Clean(n) != n || n == ".." || strings.HasPrefix(n, "../") {
+			return fmt.Errorf("%w: invalid file name %q", ErrBadNameTemplate, n)
+		}
+
+		if seen[n] {
+			return fmt.Errorf("%w: %q", ErrNameCollision, n)
+		}
+		seen[n] = true
+	}
+
+	return nil
+}
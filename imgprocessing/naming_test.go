@@ -0,0 +1,74 @@
+package imgprocessing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestParseNameTemplate(t *testing.T) {
+	testCases := []struct {
+		template string
+		wantErr  error
+	}{
+		{template: ""},
+		{template: DefaultNameTemplate},
+		{template: "tile_{col}_{row}.png"},
+		{template: "{index0}.{ext}"},
+		{template: "{x}-{y}.{ext}"},
+		{template: "{row:3}x{col:3}"},
+		{template: "{row0}_{col}"},
+		{template: "{label}.{ext}"},
+		{template: "{row}x{col}.{ext}{ext}"},
+		{template: "tile_{row}.png", wantErr: ErrNameCollision},       // every column collides
+		{template: "{name}.{ext}", wantErr: ErrNameCollision},         // every tile collides
+		{template: "{x}_{width}x{height}", wantErr: ErrNameCollision}, // y is missing
+		{template: "{z}/{x}/{y}.png", wantErr: ErrBadNameTemplate},    // unknown variable
+		{template: "{Row}x{col}", wantErr: ErrBadNameTemplate},        // variables are case sensitive
+		{template: "{row}x{col", wantErr: ErrBadNameTemplate},         // unclosed brace
+		{template: "{row}x{col}}", wantErr: ErrBadNameTemplate},       // stray brace
+		{template: "{row:0}x{col}", wantErr: ErrBadNameTemplate},      // bad padding
+		{template: "{row:10}x{col}", wantErr: ErrBadNameTemplate},     // too wide padding
+		{template: "{name:2}_{index}", wantErr: ErrBadNameTemplate},   // padding of string
+		{template: "{row}\\{col}.png", wantErr: ErrBadNameTemplate},   // backslash
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			_, err := ParseNameTemplate(tc.template)
+			if tc.wantErr == nil {
+				assert.Equal(t, err, nil)
+			} else {
+				assert.Equal(t, errors.Is(err, tc.wantErr), true, tc.template)
+			}
+		})
+	}
+}
+
+func TestNameTemplate_Execute(t *testing.T) {
+	v := TileVars{Row: 2, Col: 0, Index: 24, X: 0, Y: 64, Width: 32, Height: 32, Name: "pic", Ext: "png", Label: "logo"}
+	maxVars := TileVars{Row: 11, Col: 9, Index: 119, X: 288, Y: 352}
+
+	testCases := []struct {
+		template string
+		want     string
+	}{
+		{template: "", want: "pic_03x01.png"}, // 12 строк, 10 столбцов
+		{template: "{row0}_{col0}", want: "02_0"},
+		{template: "{index}", want: "025"},
+		{template: "{index0}", want: "024"},
+		{template: "{row:1}x{col:1}", want: "3x1"},     // без выравнивания
+		{template: "{row:4}x{col:2}", want: "0003x01"}, // заданная ширина
+		{template: "{x}-{y}", want: "000-064"},
+		{template: "{label}_{width}x{height}.{ext}", want: "logo_32x32.png"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			naming, err := ParseNameTemplate(tc.template)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, naming.Execute(v, maxVars), tc.want)
+		})
+	}
+}
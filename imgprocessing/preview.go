@@ -0,0 +1,140 @@
+package imgprocessing
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+)
+
+// DefaultPreviewSize is the longer side of preview in px.
+const DefaultPreviewSize = 800
+
+var (
+	previewLine  = color.NRGBA{R: 255, G: 0, B: 255, A: 255}
+	previewEdge  = color.NRGBA{R: 255, G: 64, B: 0, A: 96}
+	previewLabel = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	previewShade = color.NRGBA{A: 160}
+)
+
+// digitFont is 3x5 px bitmap of digits, a row per string, '#' is a set pixel.
+var digitFont = [10][5]string{
+	{"###", "#.#", "#.#", "#.#", "###"},
+	{".#.", "##.", ".#.", ".#.", "###"},
+	{"###", "..#", "###", "#..", "###"},
+	{"###", "..#", "###", "..#", "###"},
+	{"#.#", "#.#", "###", "..#", "..#"},
+	{"###", "#..", "###", "..#", "###"},
+	{"###", "#..", "###", "#.#", "###"},
+	{"###", "..#", ".#.", ".#.", ".#."},
+	{"###", "#.#", "###", "#.#", "###"},
+	{"###", "#.#", "###", "..#", "###"},
+}
+
+// PreviewOptions configures RenderPreview.
+type PreviewOptions struct {
+	MaxSize        int  // longer side of preview, DefaultPreviewSize if 0
+	HighlightEdges bool // tiles smaller than the first one are highlighted, it makes sense for grids
+}
+
+// RenderPreview draws img downscaled to opts.MaxSize with outlines of tiles
+// and their numbers in reading order. In a grid tiles smaller than the first one
+// are the rest at right and bottom edges.
+func RenderPreview(img image.Image, tiles []image.Rectangle, opts PreviewOptions) *image.NRGBA {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultPreviewSize
+	}
+
+	b := img.Bounds()
+	scale := math.Min(1, float64(opts.MaxSize)/float64(maxInt(b.Dx(), b.Dy())))
+	var dst *image.NRGBA
+
+	if scale < 1 {
+		dst = Resize(img, scaled(b.Size(), scale), FilterBilinear)
+	} else {
+		// рисуем поверх копии, исходное изображение не меняется
+		dst = image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	}
+
+	if len(tiles) == 0 {
+		return dst
+	}
+
+	full := tiles[0].Size()
+	toPreview := func(r image.Rectangle) image.Rectangle {
+		r = r.Sub(b.Min)
+		return image.Rect(
+			int(float64(r.Min.X)*scale), int(float64(r.Min.Y)*scale),
+			int(math.Ceil(float64(r.Max.X)*scale)), int(math.Ceil(float64(r.Max.Y)*scale)),
+		).Intersect(dst.Bounds())
+	}
+
+	for _, t := range tiles {
+		if opts.HighlightEdges && (t.Dx() < full.X || t.Dy() < full.Y) {
+			draw.Draw(dst, toPreview(t), image.NewUniform(previewEdge), image.Point{}, draw.Over)
+		}
+	}
+
+	for i, t := range tiles {
+		r := toPreview(t)
+		outline(dst, r, previewLine)
+		label(dst, r, strconv.Itoa(i+1))
+	}
+
+	return dst
+}
+
+func outline(dst *image.NRGBA, r image.Rectangle, c color.NRGBA) {
+	if r.Empty() {
+		return
+	}
+
+	for x := r.Min.X; x < r.Max.X; x++ {
+		dst.SetNRGBA(x, r.Min.Y, c)
+		dst.SetNRGBA(x, r.Max.Y-1, c)
+	}
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		dst.SetNRGBA(r.Min.X, y, c)
+		dst.SetNRGBA(r.Max.X-1, y, c)
+	}
+}
+
+// label writes number in top left corner of r on dark background, if it fits.
+func label(dst *image.NRGBA, r image.Rectangle, text string) {
+	const digitWidth, digitHeight, pad = 4, 5, 2
+
+	box := image.Rect(0, 0, len(text)*digitWidth+pad*2-1, digitHeight+pad*2).Add(r.Min.Add(image.Pt(1, 1)))
+	if !box.In(r) {
+		return
+	}
+
+	draw.Draw(dst, box, image.NewUniform(previewShade), image.Point{}, draw.Over)
+
+	for i, ch := range text {
+		glyph := digitFont[ch-'0']
+		x0, y0 := box.Min.X+pad+i*digitWidth, box.Min.Y+pad
+
+		for y, row := range glyph {
+			for x := range row {
+				if row[x] == '#' {
+					dst.SetNRGBA(x0+x, y0+y, previewLabel)
+				}
+			}
+		}
+	}
+}
+
+// GridRects returns bounds of tiles of grid in reading order.
+func GridRects(images [][]image.Image) []image.Rectangle {
+	rects := make([]image.Rectangle, 0)
+	for _, row := range images {
+		for _, tile := range row {
+			rects = append(rects, tile.Bounds())
+		}
+	}
+
+	return rects
+}
@@ -0,0 +1,35 @@
+package imgprocessing
+
+import (
+	"image"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestRenderPreview(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 1000, 500))
+	images, err := CutImage(img, 300, 300)
+	assert.Equal(t, err, nil)
+
+	preview := RenderPreview(img, GridRects(images), PreviewOptions{MaxSize: 200, HighlightEdges: true})
+	assert.Equal(t, preview.Bounds(), image.Rect(0, 0, 200, 100))
+
+	// outline of the first tile and highlighted last one
+	assert.Equal(t, preview.NRGBAAt(0, 50), previewLine)
+	assert.Equal(t, preview.NRGBAAt(190, 90).R > 0, true)
+	// inside of the first tile is untouched
+	assert.Equal(t, preview.NRGBAAt(50, 50).A, uint8(0))
+	// source is not modified
+	assert.Equal(t, img.NRGBAAt(0, 0).A, uint8(0))
+}
+
+func TestRenderPreview_Small(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(10, 10, 110, 60))
+	preview := RenderPreview(img, []image.Rectangle{img.Bounds()}, PreviewOptions{})
+
+	assert.Equal(t, preview.Bounds(), image.Rect(0, 0, 100, 50))
+	assert.Equal(t, preview.NRGBAAt(99, 49), previewLine)
+	// number 1 in top left corner
+	assert.Equal(t, preview.NRGBAAt(4, 4), previewLabel)
+}
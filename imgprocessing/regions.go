@@ -0,0 +1,100 @@
+package imgprocessing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+)
+
+var (
+	ErrBadRegions        = errors.New("bad regions")
+	ErrRegionOutOfBounds = errors.New("region is out of image")
+	ErrRegionsOverlap    = errors.New("regions overlap")
+)
+
+// RegionsNameTemplate saves every region under its own name.
+const RegionsNameTemplate = "{label}.{ext}"
+
+// Region is a named rectangle to cut out of image.
+type Region struct {
+	Name string `json:"name"`
+	Rect
+}
+
+func (r Region) rectangle() image.Rectangle {
+	return image.Rect(r.X, r.Y, r.X+r.Width, r.Y+r.Height)
+}
+
+// ParseRegions reads json array of regions: [{"name": "logo", "x": 0, "y": 0, "width": 120, "height": 40}].
+func ParseRegions(data []byte) ([]Region, error) {
+	var regions []Region
+	if err := json.Unmarshal(data, &regions); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadRegions, err)
+	}
+
+	return regions, ValidateRegions(regions)
+}
+
+// ValidateRegions checks regions without image: names are set and unique,
+// sizes are positive and regions do not overlap. Bounds are checked by CutRegions.
+func ValidateRegions(regions []Region) error {
+	if len(regions) == 0 {
+		return fmt.Errorf("%w: no regions", ErrBadRegions)
+	}
+
+	names := make(map[string]bool, len(regions))
+
+	for i, r := range regions {
+		if r.Name == "" {
+			return fmt.Errorf("%w: region %d has no name", ErrBadRegions, i+1)
+		}
+
+		if names[r.Name] {
+			return fmt.Errorf("%w: duplicate name %q", ErrBadRegions, r.Name)
+		}
+		names[r.Name] = true
+
+		if r.X < 0 || r.Y < 0 || r.Width < 1 || r.Height < 1 {
+			return fmt.Errorf("%w: %q is %s", ErrBadRegions, r.Name, r.Rect)
+		}
+
+		for _, other := range regions[:i] {
+			if r.rectangle().Overlaps(other.rectangle()) {
+				return fmt.Errorf("%w: %q and %q", ErrRegionsOverlap, other.Name, r.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// CutRegions cuts regions out of img in their order, pieces share pixels with img.
+// Region coordinates are relative to top left corner of img.
+func CutRegions(img image.Image, regions []Region) ([]image.Image, error) {
+	if err := ValidateRegions(regions); err != nil {
+		return nil, err
+	}
+
+	b := img.Bounds()
+	rects := make([]image.Rectangle, len(regions))
+
+	for i, r := range regions {
+		rects[i] = r.rectangle().Add(b.Min)
+		if !rects[i].In(b) {
+			return nil, fmt.Errorf("%w: %q is %s, image is %dx%d px", ErrRegionOutOfBounds, r.Name, r.Rect, b.Dx(), b.Dy())
+		}
+	}
+
+	return CutRects(img, rects)
+}
+
+// RegionNames returns names of regions, they are {label} of pieces.
+func RegionNames(regions []Region) []string {
+	names := make([]string, len(regions))
+	for i, r := range regions {
+		names[i] = r.Name
+	}
+
+	return names
+}
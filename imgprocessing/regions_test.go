@@ -0,0 +1,72 @@
+package imgprocessing
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestParseRegions(t *testing.T) {
+	regions, err := ParseRegions([]byte(`[
+		{"name": "logo", "x": 0, "y": 0, "width": 120, "height": 40},
+		{"name": "buttons/ok", "x": 120, "y": 0, "width": 80, "height": 24}
+	]`))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, regions[1], Region{Name: "buttons/ok", Rect: Rect{X: 120, Width: 80, Height: 24}})
+
+	tests := []struct {
+		name string
+		spec string
+		err  error
+	}{
+		{name: "not json", spec: `{"name"`, err: ErrBadRegions},
+		{name: "empty", spec: `[]`, err: ErrBadRegions},
+		{name: "no name", spec: `[{"x": 0, "y": 0, "width": 1, "height": 1}]`, err: ErrBadRegions},
+		{name: "zero size", spec: `[{"name": "a", "width": 0, "height": 1}]`, err: ErrBadRegions},
+		{name: "duplicate", spec: `[{"name": "a", "width": 1, "height": 1}, {"name": "a", "x": 5, "width": 1, "height": 1}]`, err: ErrBadRegions},
+		{name: "overlap", spec: `[{"name": "a", "width": 10, "height": 10}, {"name": "b", "x": 9, "y": 9, "width": 10, "height": 10}]`, err: ErrRegionsOverlap},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseRegions([]byte(tc.spec))
+			assert.Equal(t, errors.Is(err, tc.err), true)
+		})
+	}
+}
+
+func TestCutRegions(t *testing.T) {
+	regions := []Region{
+		{Name: "logo", Rect: Rect{X: 0, Y: 0, Width: 50, Height: 20}},
+		{Name: "buttons/ok", Rect: Rect{X: 60, Y: 40, Width: 40, Height: 30}},
+	}
+
+	pieces, err := CutRegions(gradient(), regions)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, pieces[1].Bounds(), image.Rect(60, 40, 100, 70))
+
+	_, err = CutRegions(gradient(), []Region{{Name: "big", Rect: Rect{X: 60, Y: 40, Width: 41, Height: 30}}})
+	assert.Equal(t, errors.Is(err, ErrRegionOutOfBounds), true)
+
+	naming, err := ParseNameTemplate(RegionsNameTemplate)
+	assert.Equal(t, err, nil)
+
+	buf := bytes.Buffer{}
+	manifest, err := PackImages(zip.NewWriter(&buf), [][]image.Image{pieces}, PackOptions{
+		Format: FormatPNG, Naming: naming, Labels: RegionNames(regions),
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, manifest.Tiles[0].File, "logo.png")
+	assert.Equal(t, manifest.Tiles[1].File, "buttons/ok.png")
+	assert.Equal(t, manifest.Tiles[1].Label, "buttons/ok")
+
+	// region can not replace manifest
+	naming, err = ParseNameTemplate("{label}")
+	assert.Equal(t, err, nil)
+	_, err = Layout([][]image.Image{pieces[:1]}, PackOptions{Naming: naming, Labels: []string{ManifestFile}})
+	assert.Equal(t, errors.Is(err, ErrNameCollision), true)
+}
@@ -0,0 +1,230 @@
+package imgprocessing
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+)
+
+var ErrUnknownFilter = errors.New("unknown resampling filter")
+
+// toNRGBA returns img itself if it is *image.NRGBA with zero origin, a converted copy otherwise.
+func toNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok && n.Bounds().Min == (image.Point{}) {
+		return n
+	}
+
+	b := img.Bounds()
+	n := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(n, n.Bounds(), img, b.Min, draw.Src)
+
+	return n
+}
+
+// halve downscales image twice with 2x2 box filter, odd last row and column are averaged alone.
+// Colors are weighted by alpha, so transparent pixels do not darken edges.
+func halve(src *image.NRGBA) *image.NRGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, (sw+1)/2, (sh+1)/2))
+
+	for y := 0; y < dst.Bounds().Dy(); y++ {
+		for x := 0; x < dst.Bounds().Dx(); x++ {
+			var r, g, b, a, n uint32
+
+			for dy := 0; dy < 2; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					sx, sy := 2*x+dx, 2*y+dy
+					if sx >= sw || sy >= sh {
+						continue
+					}
+
+					i := src.PixOffset(sx, sy)
+					pa := uint32(src.Pix[i+3])
+					r += uint32(src.Pix[i]) * pa
+					g += uint32(src.Pix[i+1]) * pa
+					b += uint32(src.Pix[i+2]) * pa
+					a += pa
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			if a > 0 {
+				dst.Pix[i] = uint8(r / a)
+				dst.Pix[i+1] = uint8(g / a)
+				dst.Pix[i+2] = uint8(b / a)
+			}
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// Filter is resampling filter used by Resize.
+type Filter string
+
+const (
+	FilterNearest    Filter = "nearest"
+	FilterBilinear   Filter = "bilinear"
+	FilterCatmullRom Filter = "catmull-rom"
+	FilterLanczos    Filter = "lanczos"
+)
+
+// ParseFilter returns FilterCatmullRom for empty string.
+func ParseFilter(s string) (Filter, error) {
+	switch Filter(s) {
+	case "":
+		return FilterCatmullRom, nil
+	case FilterNearest, FilterBilinear, FilterCatmullRom, FilterLanczos:
+		return Filter(s), nil
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrUnknownFilter, s)
+}
+
+// kernel returns filter function and its radius in source px at scale 1.
+func (f Filter) kernel() (func(x float64) float64, float64) {
+	switch f {
+	case FilterNearest:
+		return func(x float64) float64 {
+			if x >= -0.5 && x < 0.5 {
+				return 1
+			}
+			return 0
+		}, 0.5
+	case FilterBilinear:
+		return func(x float64) float64 {
+			return math.Max(0, 1-math.Abs(x))
+		}, 1
+	case FilterLanczos:
+		return func(x float64) float64 {
+			if x == 0 {
+				return 1
+			}
+			if math.Abs(x) >= 3 {
+				return 0
+			}
+			return 3 * math.Sin(math.Pi*x) * math.Sin(math.Pi*x/3) / (math.Pi * math.Pi * x * x)
+		}, 3
+	}
+
+	// Catmull-Rom: cubic with B = 0, C = 0.5
+	return func(x float64) float64 {
+		x = math.Abs(x)
+		switch {
+		case x < 1:
+			return (3*x*x*x - 5*x*x + 2) / 2
+		case x < 2:
+			return (-x*x*x + 5*x*x - 8*x + 4) / 2
+		}
+		return 0
+	}, 2
+}
+
+// contribution lists source pixels and their normalized weights for one destination pixel.
+type contribution struct {
+	index  []int
+	weight []float64
+}
+
+// contributions of src px to every of dst px along one axis. When downscaling kernel is
+// stretched, so every source pixel is taken into account. Nearest filter takes one pixel.
+func contributions(src, dst int, f Filter) []contribution {
+	k, radius := f.kernel()
+	scale := float64(src) / float64(dst)
+	stretch := math.Max(scale, 1)
+	out := make([]contribution, dst)
+
+	for i := range out {
+		center := (float64(i) + 0.5) * scale
+
+		if f == FilterNearest {
+			j := minInt(int(center), src-1)
+			out[i] = contribution{index: []int{j}, weight: []float64{1}}
+			continue
+		}
+
+		c := contribution{}
+		sum := 0.0
+
+		for j := int(math.Floor(center - radius*stretch)); j <= int(math.Ceil(center+radius*stretch)); j++ {
+			w := k((float64(j) + 0.5 - center) / stretch)
+			if w == 0 {
+				continue
+			}
+
+			c.index = append(c.index, minInt(maxInt(j, 0), src-1))
+			c.weight = append(c.weight, w)
+			sum += w
+		}
+
+		for n := range c.weight {
+			c.weight[n] /= sum
+		}
+
+		out[i] = c
+	}
+
+	return out
+}
+
+// Resize scales img to size with separable filter f, result has zero origin.
+// Colors are weighted by alpha like in halve.
+func Resize(img image.Image, size image.Point, f Filter) *image.NRGBA {
+	src := toNRGBA(img)
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, size.X, size.Y))
+
+	if sw == 0 || sh == 0 || size.X <= 0 || size.Y <= 0 {
+		return dst
+	}
+
+	// premultiplied rows scaled horizontally: tmp[(y*size.X+x)*4+channel]
+	tmp := make([]float64, sh*size.X*4)
+	columns := contributions(sw, size.X, f)
+
+	for y := 0; y < sh; y++ {
+		for x, c := range columns {
+			t := tmp[(y*size.X+x)*4:]
+			for n, j := range c.index {
+				i := src.PixOffset(j, y)
+				a := float64(src.Pix[i+3]) * c.weight[n]
+				t[0] += float64(src.Pix[i]) * a
+				t[1] += float64(src.Pix[i+1]) * a
+				t[2] += float64(src.Pix[i+2]) * a
+				t[3] += a
+			}
+		}
+	}
+
+	for y, c := range contributions(sh, size.Y, f) {
+		for x := 0; x < size.X; x++ {
+			var r, g, b, a float64
+
+			for n, j := range c.index {
+				t := tmp[(j*size.X+x)*4:]
+				r += t[0] * c.weight[n]
+				g += t[1] * c.weight[n]
+				b += t[2] * c.weight[n]
+				a += t[3] * c.weight[n]
+			}
+
+			i := dst.PixOffset(x, y)
+			if a > 0 {
+				dst.Pix[i] = clampUint8(r / a)
+				dst.Pix[i+1] = clampUint8(g / a)
+				dst.Pix[i+2] = clampUint8(b / a)
+			}
+			dst.Pix[i+3] = clampUint8(a)
+		}
+	}
+
+	return dst
+}
+
+func clampUint8(v float64) uint8 {
+	return uint8(math.Min(255, math.Max(0, math.Round(v))))
+}
@@ -0,0 +1,214 @@
+package imgprocessing
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+)
+
+var (
+	ErrUnknownResize = errors.New("unknown resize mode")
+	ErrBadResize     = errors.New("bad resize size")
+)
+
+// ResizeMode tells how a tile is brought to output size.
+type ResizeMode string
+
+const (
+	// ResizeNone keeps tiles as they are cut.
+	ResizeNone ResizeMode = ""
+	// ResizeExact scales tile to Width x Height ignoring aspect ratio.
+	ResizeExact ResizeMode = "exact"
+	// ResizeFit scales tile keeping aspect ratio to fit into Width x Height.
+	ResizeFit ResizeMode = "fit"
+	// ResizeFill crops tile to aspect ratio of Width x Height around its center and scales to that size.
+	ResizeFill ResizeMode = "fill"
+	// ResizeMax scales tile keeping aspect ratio down so its longer side is not above Width.
+	ResizeMax ResizeMode = "max"
+)
+
+func ParseResizeMode(s string) (ResizeMode, error) {
+	switch ResizeMode(s) {
+	case ResizeNone, ResizeExact, ResizeFit, ResizeFill, ResizeMax:
+		return ResizeMode(s), nil
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrUnknownResize, s)
+}
+
+// ResizeOptions configures ResizeGrid.
+type ResizeOptions struct {
+	Mode   ResizeMode
+	Width  int
+	Height int    // ignored by ResizeMax
+	Filter Filter // FilterCatmullRom if empty
+}
+
+func (opts ResizeOptions) Validate() error {
+	if _, err := ParseResizeMode(string(opts.Mode)); err != nil {
+		return err
+	}
+
+	if _, err := ParseFilter(string(opts.Filter)); err != nil {
+		return err
+	}
+
+	if opts.Mode == ResizeNone {
+		return nil
+	}
+
+	if opts.Width < 1 || opts.Width > MaxTileSize || (opts.Mode != ResizeMax && (opts.Height < 1 || opts.Height > MaxTileSize)) {
+		return fmt.Errorf("%w: %dx%d px", ErrBadResize, opts.Width, opts.Height)
+	}
+
+	return nil
+}
+
+// ResizeTile returns img brought to output size, result has zero origin.
+// img itself is returned with ResizeNone or if size is already right.
+func ResizeTile(img image.Image, opts ResizeOptions) image.Image {
+	b := img.Bounds()
+	size, crop := opts.target(b, opts.scale(b.Size()))
+
+	return resizeTo(img, size, crop, opts.Filter)
+}
+
+// ResizeGrid resizes every tile of grid and keeps it a grid. With ResizeFit and ResizeMax all tiles
+// are scaled by the same factor, taken from the largest tile, so smaller edge tiles stay in proportion
+// and no padding is needed. Bounds of tiles (and coordinates in manifest) describe the grid of output
+// tiles, so the archive can be stitched back. Resized tiles are kept in memory, so their total area
+// is limited by MaxStitchPixels as the result of ApplyTransform.
+func ResizeGrid(images [][]image.Image, opts ResizeOptions) ([][]image.Image, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	if opts.Mode == ResizeNone {
+		return images, nil
+	}
+
+	largest := image.Point{}
+	for _, row := range images {
+		for _, tile := range row {
+			largest.X = maxInt(largest.X, tile.Bounds().Dx())
+			largest.Y = maxInt(largest.Y, tile.Bounds().Dy())
+		}
+	}
+
+	scale := opts.scale(largest)
+
+	// размеры считаем заранее, чтобы не масштабировать то, что не поместится в память
+	widths, heights := []int{}, make([]int, len(images))
+	pixels := int64(0)
+
+	for row := range images {
+		for col, tile := range images[row] {
+			size, _ := opts.target(tile.Bounds(), scale)
+			pixels += int64(size.X) * int64(size.Y)
+
+			if col == len(widths) {
+				widths = append(widths, 0)
+			}
+
+			widths[col] = maxInt(widths[col], size.X)
+			heights[row] = maxInt(heights[row], size.Y)
+		}
+	}
+
+	if pixels > MaxStitchPixels {
+		return nil, fmt.Errorf("%w: resized tiles take %d px", ErrBadTransform, pixels)
+	}
+
+	out := make([][]image.Image, len(images))
+	y := 0
+
+	for row := range images {
+		out[row] = make([]image.Image, len(images[row]))
+		x := 0
+
+		for col, tile := range images[row] {
+			size, crop := opts.target(tile.Bounds(), scale)
+			out[row][col] = moveTo(resizeTo(tile, size, crop, opts.Filter), image.Pt(x, y))
+			x += widths[col]
+		}
+
+		y += heights[row]
+	}
+
+	return out, nil
+}
+
+// scale returns factor of ResizeFit and ResizeMax for a tile of given size.
+func (opts ResizeOptions) scale(size image.Point) float64 {
+	switch opts.Mode {
+	case ResizeFit:
+		return math.Min(float64(opts.Width)/float64(size.X), float64(opts.Height)/float64(size.Y))
+	case ResizeMax:
+		if longest := maxInt(size.X, size.Y); longest > opts.Width {
+			return float64(opts.Width) / float64(longest)
+		}
+	}
+
+	return 1
+}
+
+// target returns output size of tile with bounds b and the part of it to be scaled.
+func (opts ResizeOptions) target(b image.Rectangle, scale float64) (image.Point, image.Rectangle) {
+	switch opts.Mode {
+	case ResizeExact:
+		return image.Pt(opts.Width, opts.Height), b
+	case ResizeFit, ResizeMax:
+		return scaled(b.Size(), scale), b
+	case ResizeFill:
+		size := image.Pt(opts.Width, opts.Height)
+		// the widest part of tile with aspect ratio of size
+		w := maxInt(1, minInt(b.Dx(), int(math.Round(float64(b.Dy())*float64(size.X)/float64(size.Y)))))
+		h := maxInt(1, minInt(b.Dy(), int(math.Round(float64(b.Dx())*float64(size.Y)/float64(size.X)))))
+		min := b.Min.Add(image.Pt((b.Dx()-w)/2, (b.Dy()-h)/2))
+
+		return size, image.Rectangle{Min: min, Max: min.Add(image.Pt(w, h))}
+	}
+
+	return b.Size(), b
+}
+
+func resizeTo(img image.Image, size image.Point, crop image.Rectangle, filter Filter) image.Image {
+	b := img.Bounds()
+	if size == b.Size() && crop == b {
+		return img
+	}
+
+	if crop != b {
+		subImager, err := castSubImager(img)
+		if err == nil {
+			img = subImager.SubImage(crop)
+		}
+	}
+
+	f, _ := ParseFilter(string(filter))
+
+	return Resize(img, size, f)
+}
+
+// moveTo returns img with bounds starting at min, NRGBA pixels are shared, not copied.
+func moveTo(img image.Image, min image.Point) image.Image {
+	b := img.Bounds()
+
+	if n, ok := img.(*image.NRGBA); ok {
+		moved := *n
+		moved.Rect = b.Add(min.Sub(b.Min))
+
+		return &moved
+	}
+
+	dst := image.NewNRGBA(b.Sub(b.Min).Add(min))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+
+	return dst
+}
+
+func scaled(size image.Point, scale float64) image.Point {
+	return image.Pt(maxInt(1, int(math.Round(float64(size.X)*scale))), maxInt(1, int(math.Round(float64(size.Y)*scale))))
+}
@@ -0,0 +1,168 @@
+package imgprocessing
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestResizeTile(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 200, 100))
+
+	tests := []struct {
+		name string
+		opts ResizeOptions
+		size image.Point
+	}{
+		{name: "none", opts: ResizeOptions{}, size: image.Pt(200, 100)},
+		{name: "exact", opts: ResizeOptions{Mode: ResizeExact, Width: 50, Height: 50}, size: image.Pt(50, 50)},
+		{name: "fit", opts: ResizeOptions{Mode: ResizeFit, Width: 50, Height: 50}, size: image.Pt(50, 25)},
+		{name: "fill", opts: ResizeOptions{Mode: ResizeFill, Width: 50, Height: 50}, size: image.Pt(50, 50)},
+		{name: "max", opts: ResizeOptions{Mode: ResizeMax, Width: 40}, size: image.Pt(40, 20)},
+		{name: "max no upscale", opts: ResizeOptions{Mode: ResizeMax, Width: 400}, size: image.Pt(200, 100)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.opts.Validate(), nil)
+			assert.Equal(t, ResizeTile(src, tc.opts).Bounds().Size(), tc.size)
+		})
+	}
+
+	for _, opts := range []ResizeOptions{{Mode: "big"}, {Mode: ResizeFit, Width: 10}, {Mode: ResizeMax}, {Filter: "box"}} {
+		assert.Equal(t, opts.Validate() != nil, true)
+	}
+}
+
+func TestResize_Filters(t *testing.T) {
+	// left half red, right half blue
+	src := image.NewNRGBA(image.Rect(0, 0, 64, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= 32 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			src.SetNRGBA(x, y, c)
+		}
+	}
+
+	for _, f := range []Filter{FilterNearest, FilterBilinear, FilterCatmullRom, FilterLanczos} {
+		for _, size := range []image.Point{{16, 8}, {160, 80}} {
+			dst := Resize(src, size, f)
+			assert.Equal(t, dst.Bounds().Size(), size)
+			// corners stay pure, colors do not leak far from the edge
+			assert.Equal(t, dst.NRGBAAt(0, 0), color.NRGBA{R: 255, A: 255}, string(f))
+			assert.Equal(t, dst.NRGBAAt(size.X-1, size.Y-1), color.NRGBA{B: 255, A: 255}, string(f))
+		}
+	}
+}
+
+func TestResizeGrid(t *testing.T) {
+	images, err := CutImage(image.NewNRGBA(image.Rect(0, 0, 100, 70)), 32, 32)
+	assert.Equal(t, err, nil)
+
+	resized, err := ResizeGrid(images, ResizeOptions{Mode: ResizeExact, Width: 10, Height: 20})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(resized), 3)
+	assert.Equal(t, resized[2][3].Bounds(), image.Rect(30, 40, 40, 60))
+
+	// Layout names tiles by their new bounds
+	entries, err := Layout(resized, PackOptions{Name: "pic"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, entries[len(entries)-1].Bounds, image.Rect(30, 40, 40, 60))
+}
+
+func TestResizeGrid_SameScale(t *testing.T) {
+	// 100x70 режется на 32x32: последний столбец 4 px, последняя строка 6 px
+	images, err := CutImage(image.NewNRGBA(image.Rect(0, 0, 100, 70)), 32, 32)
+	assert.Equal(t, err, nil)
+
+	resized, err := ResizeGrid(images, ResizeOptions{Mode: ResizeFit, Width: 64, Height: 48})
+	assert.Equal(t, err, nil)
+
+	// все куски масштабируются в 1.5 раза по самому большому: 32x32 -> 48x48, 4x6 -> 6x9
+	assert.Equal(t, resized[0][0].Bounds(), image.Rect(0, 0, 48, 48))
+	assert.Equal(t, resized[0][3].Bounds(), image.Rect(144, 0, 150, 48))
+	assert.Equal(t, resized[2][0].Bounds(), image.Rect(0, 96, 48, 105))
+	assert.Equal(t, resized[2][3].Bounds(), image.Rect(144, 96, 150, 105))
+
+	resized, err = ResizeGrid(images, ResizeOptions{Mode: ResizeMax, Width: 16})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, GridBounds(resized), image.Rect(0, 0, 50, 35))
+}
+
+func TestResizeGrid_TooBig(t *testing.T) {
+	images, err := CutImage(image.NewNRGBA(image.Rect(0, 0, 640, 640)), 32, 32)
+	assert.Equal(t, err, nil)
+
+	// 400 кусков по 4096x4096 px
+	_, err = ResizeGrid(images, ResizeOptions{Mode: ResizeExact, Width: MaxTileSize, Height: MaxTileSize})
+	assert.Equal(t, errors.Is(err, ErrBadTransform), true)
+}
+
+func TestResizeGrid_JPEG(t *testing.T) {
+	images, err := CutImage(gradient(), 32, 32)
+	assert.Equal(t, err, nil)
+
+	resized, err := ResizeGrid(images, ResizeOptions{Mode: ResizeFit, Width: 64, Height: 64})
+	assert.Equal(t, err, nil)
+
+	buf := bytes.Buffer{}
+	zipWriter := zip.NewWriter(&buf)
+	_, err = PackImages(zipWriter, resized, PackOptions{
+		Name: "pic", Format: FormatJPEG, Source: SourceInfo{Width: 100, Height: 70}, Canvas: GridBounds(resized).Size(),
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, zipWriter.Close(), nil)
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Equal(t, err, nil)
+
+	stitched, err := Stitch(archive)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, stitched.Bounds(), image.Rect(0, 0, 200, 140))
+
+	// у крайних кусков нет чёрных полей: правый нижний угол того же цвета, что и у исходника
+	near := func(a, b uint8) bool { return a > b-16 && a < b+16 }
+	c := color.NRGBAModel.Convert(stitched.At(198, 138)).(color.NRGBA)
+	assert.Equal(t, near(c.R, 99) && near(c.G, 69) && near(c.B, 168), true, fmt.Sprint(c))
+}
+
+func TestResizeGrid_Stitch(t *testing.T) {
+	for _, opts := range []ResizeOptions{
+		{Mode: ResizeExact, Width: 20, Height: 10},
+		{Mode: ResizeFit, Width: 64, Height: 48},
+		{Mode: ResizeFill, Width: 16, Height: 16},
+		{Mode: ResizeMax, Width: 20},
+	} {
+		t.Run(string(opts.Mode), func(t *testing.T) {
+			images, err := CutImage(gradient(), 32, 32)
+			assert.Equal(t, err, nil)
+
+			resized, err := ResizeGrid(images, opts)
+			assert.Equal(t, err, nil)
+
+			buf := bytes.Buffer{}
+			zipWriter := zip.NewWriter(&buf)
+			_, err = PackImages(zipWriter, resized, PackOptions{
+				Name: "pic", Format: FormatPNG, Source: SourceInfo{Width: 100, Height: 70}, Canvas: GridBounds(resized).Size(),
+			})
+			assert.Equal(t, err, nil)
+			assert.Equal(t, zipWriter.Close(), nil)
+
+			archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			assert.Equal(t, err, nil)
+
+			stitched, err := Stitch(archive)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, stitched.Bounds().Size(), GridBounds(resized).Size())
+		})
+	}
+}
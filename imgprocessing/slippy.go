@@ -0,0 +1,169 @@
+package imgprocessing
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"html/template"
+	"image"
+	"image/draw"
+)
+
+var ErrBadZoom = errors.New("bad zoom range")
+
+// SlippyTileSize is side of XYZ / TMS tile in px.
+const SlippyTileSize = 256
+
+// SlippyOptions configures PackSlippyTiles.
+type SlippyOptions struct {
+	MinZoom int
+	MaxZoom int    // 0 means NativeZoom of image
+	TMS     bool   // y axis goes from bottom to top, XYZ (Google, OSM) otherwise
+	Format  Format // png if empty
+}
+
+func (opts SlippyOptions) Validate() error {
+	if opts.MinZoom < 0 || opts.MaxZoom < 0 || (opts.MaxZoom > 0 && opts.MinZoom > opts.MaxZoom) {
+		return fmt.Errorf("%w: %d..%d", ErrBadZoom, opts.MinZoom, opts.MaxZoom)
+	}
+
+	return nil
+}
+
+// NativeZoom returns zoom where image has its own size. At zoom 0 it fits one tile,
+// every next zoom is twice bigger.
+func NativeZoom(size image.Point) int {
+	return countHalvings((maxInt(size.X, size.Y) + SlippyTileSize - 1) / SlippyTileSize)
+}
+
+// PackSlippyTiles writes "{z}/{x}/{y}.<ext>" tiles of img for zooms MinZoom..MaxZoom and "index.html"
+// Leaflet viewer of them. Zooms below native are made by halving, tiles at right and bottom edges
+// are padded with transparent (black for jpeg) pixels. Returns number of zooms and tiles.
+func PackSlippyTiles(dest *zip.Writer, img image.Image, opts SlippyOptions) (zooms int, tiles int, err error) {
+	if opts.Format == "" {
+		opts.Format = FormatPNG
+	}
+
+	if err := opts.Validate(); err != nil {
+		return 0, 0, err
+	}
+
+	b := img.Bounds()
+	if b.Empty() {
+		return 0, 0, ErrEmptyGrid
+	}
+
+	native := NativeZoom(b.Size())
+	if opts.MaxZoom == 0 {
+		opts.MaxZoom = native
+	}
+
+	if opts.MaxZoom > native || opts.MinZoom > opts.MaxZoom {
+		return 0, 0, fmt.Errorf("%w: %d..%d, image allows 0..%d", ErrBadZoom, opts.MinZoom, opts.MaxZoom, native)
+	}
+
+	level := toNRGBA(img)
+
+	for z := native; z >= opts.MinZoom; z-- {
+		if z <= opts.MaxZoom {
+			n, err := packSlippyZoom(dest, level, z, opts)
+			if err != nil {
+				return 0, 0, err
+			}
+
+			tiles += n
+		}
+
+		if z > opts.MinZoom {
+			level = halve(level)
+		}
+	}
+
+	w, err := dest.Create("index.html")
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable write zip archive: %w", err)
+	}
+
+	err = slippyPreview.Execute(w, map[string]any{
+		"MinZoom":  opts.MinZoom,
+		"MaxZoom":  opts.MaxZoom,
+		"Native":   native,
+		"TMS":      opts.TMS,
+		"Ext":      opts.Format.Ext(),
+		"TileSize": SlippyTileSize,
+		"Width":    b.Dx(),
+		"Height":   b.Dy(),
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable write zip archive: %w", err)
+	}
+
+	return opts.MaxZoom - opts.MinZoom + 1, tiles, nil
+}
+
+// packSlippyZoom pads level up to whole tiles and cuts it with CutImage.
+func packSlippyZoom(dest *zip.Writer, level *image.NRGBA, z int, opts SlippyOptions) (int, error) {
+	cols := (level.Bounds().Dx() + SlippyTileSize - 1) / SlippyTileSize
+	rows := (level.Bounds().Dy() + SlippyTileSize - 1) / SlippyTileSize
+	canvas := image.NewNRGBA(image.Rect(0, 0, cols*SlippyTileSize, rows*SlippyTileSize))
+	draw.Draw(canvas, level.Bounds(), level, image.Point{}, draw.Src)
+
+	images, err := CutImage(canvas, SlippyTileSize, SlippyTileSize)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+
+	for row := range images {
+		for col, tile := range images[row] {
+			y := row
+			if opts.TMS {
+				y = 1<<z - 1 - row
+			}
+
+			f, err := dest.Create(fmt.Sprintf("%d/%d/%d.%s", z, col, y, opts.Format.Ext()))
+			if err != nil {
+				return 0, fmt.Errorf("unable write zip archive: %w", err)
+			}
+
+			if err := Encode(f, tile, opts.Format); err != nil {
+				return 0, fmt.Errorf("unable write zip archive: %w", err)
+			}
+
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// slippyPreview shows tiles with Leaflet in CRS.Simple, one map unit is one px at zoom 0.
+var slippyPreview = template.Must(template.New("index.html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8" />
+  <title>imgcutter tiles</title>
+  <link rel="stylesheet" href="https://unpkg.com/leaflet@1.9.4/dist/leaflet.css" />
+  <script src="https://unpkg.com/leaflet@1.9.4/dist/leaflet.js"></script>
+  <style>html, body, #map { height: 100%; margin: 0; }</style>
+</head>
+<body>
+  <div id="map"></div>
+  <script>
+    var tms = {{.TMS}};
+    var scale = Math.pow(2, {{.Native}});
+    var bounds = [[-{{.Height}} / scale, 0], [0, {{.Width}} / scale]];
+    var Tiles = L.TileLayer.extend({
+      getTileUrl: function (c) {
+        var y = tms ? Math.pow(2, c.z) - 1 - c.y : c.y;
+        return c.z + "/" + c.x + "/" + y + "." + {{.Ext}};
+      }
+    });
+    var map = L.map("map", { crs: L.CRS.Simple, minZoom: {{.MinZoom}}, maxZoom: {{.MaxZoom}} });
+    new Tiles("", { tileSize: {{.TileSize}}, bounds: bounds, noWrap: true }).addTo(map);
+    map.fitBounds(bounds);
+  </script>
+</body>
+</html>
+`))
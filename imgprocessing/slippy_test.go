@@ -0,0 +1,70 @@
+package imgprocessing
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestPackSlippyTiles(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 600, 300))
+	assert.Equal(t, NativeZoom(img.Bounds().Size()), 2)
+	assert.Equal(t, NativeZoom(image.Pt(256, 1)), 0)
+
+	tests := []struct {
+		name  string
+		opts  SlippyOptions
+		zooms int
+		files []string // some of expected tiles
+	}{
+		{
+			name:  "xyz",
+			opts:  SlippyOptions{},
+			zooms: 3,
+			// 600x300 is 3x2 tiles, 300x150 is 2x1, 150x75 is 1
+			files: []string{"2/0/0.png", "2/2/1.png", "1/1/0.png", "0/0/0.png"},
+		},
+		{
+			name:  "tms",
+			opts:  SlippyOptions{MinZoom: 1, TMS: true},
+			zooms: 2,
+			files: []string{"2/0/3.png", "2/2/2.png", "1/1/1.png"},
+		},
+		{
+			name:  "single zoom",
+			opts:  SlippyOptions{MinZoom: 1, MaxZoom: 1, Format: FormatJPEG},
+			zooms: 1,
+			files: []string{"1/0/0.jpeg", "1/1/0.jpeg"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			zipWriter := zip.NewWriter(&buf)
+			zooms, tiles, err := PackSlippyTiles(zipWriter, img, tc.opts)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, zipWriter.Close(), nil)
+			assert.Equal(t, zooms, tc.zooms)
+
+			archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(archive.File), tiles+1)
+			archiveFile(t, archive, "index.html")
+
+			for _, name := range tc.files {
+				cfg, err := decodeZipConfig(archiveFile(t, archive, name))
+				assert.Equal(t, err, nil)
+				assert.Equal(t, image.Pt(cfg.Width, cfg.Height), image.Pt(SlippyTileSize, SlippyTileSize), name)
+			}
+		})
+	}
+
+	for _, opts := range []SlippyOptions{{MaxZoom: 3}, {MinZoom: 3}, {MinZoom: 2, MaxZoom: 1}, {MinZoom: -1}} {
+		_, _, err := PackSlippyTiles(zip.NewWriter(&bytes.Buffer{}), img, opts)
+		assert.Equal(t, err != nil, true)
+	}
+}
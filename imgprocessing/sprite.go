@@ -0,0 +1,214 @@
+package imgprocessing
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Sprite is a named image to be placed on a sheet.
+type Sprite struct {
+	Name  string // frame name in atlas, usually file name
+	Image image.Image
+}
+
+// SpriteOptions configures PackSpriteSheet.
+type SpriteOptions struct {
+	Name    string // base name of sheet files, "sheet" if empty
+	Format  Format // format of sheet, png if empty
+	Padding int    // transparent gap between sprites in px
+}
+
+// atlasRect and other atlas types follow TexturePacker "JSON (Hash)" format.
+type atlasRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type atlasSize struct {
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type atlasFrame struct {
+	Frame            atlasRect `json:"frame"`
+	Rotated          bool      `json:"rotated"`
+	Trimmed          bool      `json:"trimmed"`
+	SpriteSourceSize atlasRect `json:"spriteSourceSize"`
+	SourceSize       atlasSize `json:"sourceSize"`
+}
+
+type atlasMeta struct {
+	App     string    `json:"app"`
+	Version string    `json:"version"`
+	Image   string    `json:"image"`
+	Format  string    `json:"format"`
+	Size    atlasSize `json:"size"`
+	Scale   string    `json:"scale"`
+}
+
+// Atlas describes positions of sprites on sheet.
+type Atlas struct {
+	Frames map[string]atlasFrame `json:"frames"`
+	Meta   atlasMeta             `json:"meta"`
+}
+
+// PackSprites places sprites on a sheet and returns their rectangles in order of sprites.
+// It is a shelf packer: sprites sorted by height fill rows of a sheet about as wide as it is tall.
+func PackSprites(sprites []Sprite, padding int) (image.Rectangle, []image.Rectangle) {
+	if len(sprites) == 0 {
+		return image.Rectangle{}, nil
+	}
+
+	order := make([]int, len(sprites))
+	area, widest := 0, 0
+
+	for i, s := range sprites {
+		order[i] = i
+		b := s.Image.Bounds()
+		area += (b.Dx() + padding) * (b.Dy() + padding)
+		widest = maxInt(widest, b.Dx())
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return sprites[order[i]].Image.Bounds().Dy() > sprites[order[j]].Image.Bounds().Dy()
+	})
+
+	sheetWidth := maxInt(widest, int(math.Ceil(math.Sqrt(float64(area)))))
+	rects := make([]image.Rectangle, len(sprites))
+	x, y, shelfHeight := 0, 0, 0
+	sheet := image.Rectangle{}
+
+	for _, i := range order {
+		b := sprites[i].Image.Bounds()
+
+		if x > 0 && x+b.Dx() > sheetWidth {
+			x, y = 0, y+shelfHeight+padding
+			shelfHeight = 0
+		}
+
+		rects[i] = image.Rect(x, y, x+b.Dx(), y+b.Dy())
+		sheet = sheet.Union(rects[i])
+		x += b.Dx() + padding
+		shelfHeight = maxInt(shelfHeight, b.Dy())
+	}
+
+	return sheet, rects
+}
+
+// PackSpriteSheet writes sheet image, TexturePacker compatible json atlas and css
+// with a class per sprite into dest.
+func PackSpriteSheet(dest *zip.Writer, sprites []Sprite, opts SpriteOptions) (*Atlas, error) {
+	if len(sprites) == 0 {
+		return nil, ErrEmptyGrid
+	}
+
+	if opts.Name == "" {
+		opts.Name = "sheet"
+	}
+
+	if opts.Format == "" {
+		opts.Format = FormatPNG
+	}
+
+	bounds, rects := PackSprites(sprites, opts.Padding)
+	sheet := image.NewNRGBA(bounds)
+	imageName := fmt.Sprintf("%s.%s", opts.Name, opts.Format.Ext())
+
+	atlas := Atlas{
+		Frames: make(map[string]atlasFrame, len(sprites)),
+		Meta: atlasMeta{
+			App:     "imgcutter",
+			Version: "1.0",
+			Image:   imageName,
+			Format:  "RGBA8888",
+			Size:    atlasSize{W: bounds.Dx(), H: bounds.Dy()},
+			Scale:   "1",
+		},
+	}
+
+	classes := make(map[string]string, len(sprites)) // css class -> frame name
+
+	for i, s := range sprites {
+		if _, ok := atlas.Frames[s.Name]; ok {
+			return nil, fmt.Errorf("%w: %q", ErrNameCollision, s.Name)
+		}
+
+		// разные имена могут дать один класс: "a b.png" и "a-b.png" оба станут .sprite-a-b
+		class := spriteClass(s.Name)
+		if other, ok := classes[class]; ok {
+			return nil, fmt.Errorf("%w: %q and %q make css class %q", ErrNameCollision, other, s.Name, class)
+		}
+		classes[class] = s.Name
+
+		draw.Draw(sheet, rects[i], s.Image, s.Image.Bounds().Min, draw.Src)
+
+		w, h := rects[i].Dx(), rects[i].Dy()
+		atlas.Frames[s.Name] = atlasFrame{
+			Frame:            atlasRect{X: rects[i].Min.X, Y: rects[i].Min.Y, W: w, H: h},
+			SpriteSourceSize: atlasRect{W: w, H: h},
+			SourceSize:       atlasSize{W: w, H: h},
+		}
+	}
+
+	w, err := dest.Create(imageName)
+	if err != nil {
+		return nil, fmt.Errorf("unable write zip archive: %w", err)
+	}
+
+	if err := Encode(w, sheet, opts.Format); err != nil {
+		return nil, fmt.Errorf("unable write zip archive: %w", err)
+	}
+
+	w, err = dest.Create(opts.Name + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("unable write zip archive: %w", err)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+
+	if err := enc.Encode(atlas); err != nil {
+		return nil, fmt.Errorf("unable write zip archive: %w", err)
+	}
+
+	w, err = dest.Create(opts.Name + ".css")
+	if err != nil {
+		return nil, fmt.Errorf("unable write zip archive: %w", err)
+	}
+
+	if _, err := w.Write([]byte(spriteCSS(sprites, rects, imageName))); err != nil {
+		return nil, fmt.Errorf("unable write zip archive: %w", err)
+	}
+
+	return &atlas, nil
+}
+
+var cssUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// spriteClass is css class of frame: name without extension, unsafe characters replaced with "-".
+func spriteClass(name string) string {
+	return cssUnsafe.ReplaceAllString(strings.TrimSuffix(name, path.Ext(name)), "-")
+}
+
+// spriteCSS makes ".sprite.sprite-<class>" classes, see spriteClass.
+func spriteCSS(sprites []Sprite, rects []image.Rectangle, imageName string) string {
+	b := strings.Builder{}
+	fmt.Fprintf(&b, ".sprite {\n\tdisplay: inline-block;\n\tbackground-image: url(%q);\n\tbackground-repeat: no-repeat;\n}\n", imageName)
+
+	for i, s := range sprites {
+		fmt.Fprintf(&b, "\n.sprite-%s {\n\twidth: %dpx;\n\theight: %dpx;\n\tbackground-position: -%dpx -%dpx;\n}\n",
+			spriteClass(s.Name), rects[i].Dx(), rects[i].Dy(), rects[i].Min.X, rects[i].Min.Y)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,119 @@
+package imgprocessing
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestPackSprites(t *testing.T) {
+	sizes := []image.Point{{10, 40}, {64, 32}, {7, 7}, {100, 20}, {33, 33}, {1, 1}, {50, 60}}
+	sprites := make([]Sprite, len(sizes))
+
+	for i, size := range sizes {
+		sprites[i] = Sprite{Name: fmt.Sprint(i), Image: image.NewNRGBA(image.Rectangle{Max: size})}
+	}
+
+	for _, padding := range []int{0, 2} {
+		sheet, rects := PackSprites(sprites, padding)
+		assert.Equal(t, len(rects), len(sprites))
+
+		for i, r := range rects {
+			assert.Equal(t, r.Size(), sizes[i])
+			assert.Equal(t, r.In(sheet), true)
+
+			for j := range rects[:i] {
+				assert.Equal(t, r.Inset(-padding).Overlaps(rects[j]), false)
+			}
+		}
+	}
+}
+
+func TestPackSpriteSheet(t *testing.T) {
+	images := makeGrid(t, 2, 2)
+	entries, err := Layout(images, PackOptions{Name: "pic"})
+	assert.Equal(t, err, nil)
+
+	sprites := make([]Sprite, len(entries))
+	for i, e := range entries {
+		sprites[i] = Sprite{Name: e.File, Image: images[e.Row][e.Col]}
+	}
+
+	buf := bytes.Buffer{}
+	zipWriter := zip.NewWriter(&buf)
+	_, err = PackSpriteSheet(zipWriter, sprites, SpriteOptions{Name: "pic", Padding: 1})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, zipWriter.Close(), nil)
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(archive.File), 3)
+
+	files := map[string][]byte{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		assert.Equal(t, err, nil)
+		files[f.Name], err = io.ReadAll(r)
+		assert.Equal(t, err, nil)
+	}
+
+	var atlas Atlas
+	assert.Equal(t, json.Unmarshal(files["pic.json"], &atlas), nil)
+	assert.Equal(t, atlas.Meta.Image, "pic.png")
+	assert.Equal(t, len(atlas.Frames), 4)
+	assert.Equal(t, atlas.Frames["pic_2x2.jpeg"].Frame.W, 32)
+
+	sheet, _, err := image.Decode(bytes.NewReader(files["pic.png"]))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sheet.Bounds().Dx(), atlas.Meta.Size.W)
+
+	assert.Equal(t, strings.Contains(string(files["pic.css"]), ".sprite-pic_2x2 {"), true)
+
+	_, err = PackSpriteSheet(zip.NewWriter(&bytes.Buffer{}), []Sprite{sprites[0], sprites[0]}, SpriteOptions{})
+	assert.Equal(t, err != nil, true)
+}
+
+func TestPackSpriteSheet_ClassCollision(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+
+	testCases := []struct {
+		name    string
+		names   []string
+		wantErr error
+	}{
+		{name: "distinct", names: []string{"a.png", "b.png", "a_b.png"}},
+		{name: "space and dash", names: []string{"a b.png", "a-b.png"}, wantErr: ErrNameCollision},
+		{name: "dot and dash", names: []string{"a-b.png", "a.b.png"}, wantErr: ErrNameCollision},
+		{name: "other extension", names: []string{"a.png", "a.jpeg"}, wantErr: ErrNameCollision},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sprites := make([]Sprite, len(tc.names))
+			for i, name := range tc.names {
+				sprites[i] = Sprite{Name: name, Image: img}
+			}
+
+			buf := bytes.Buffer{}
+			zipWriter := zip.NewWriter(&buf)
+			_, err := PackSpriteSheet(zipWriter, sprites, SpriteOptions{})
+			assert.Equal(t, errors.Is(err, tc.wantErr), true)
+			assert.Equal(t, zipWriter.Close(), nil)
+
+			// при ошибке в архив ничего не пишется
+			if tc.wantErr != nil {
+				archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+				assert.Equal(t, err, nil)
+				assert.Equal(t, len(archive.File), 0)
+			}
+		})
+	}
+}
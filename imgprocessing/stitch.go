@@ -0,0 +1,310 @@
+package imgprocessing
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var (
+	ErrBadArchive  = errors.New("archive is not a tile set")
+	ErrBadGrid     = errors.New("tiles do not form a grid")
+	ErrImageTooBig = errors.New("image too big")
+)
+
+// MaxStitchPixels limits size of stitched image, tiles are decompressed into memory.
+const MaxStitchPixels = 200_000_000
+
+// defaultTileName matches names built with DefaultNameTemplate.
+var defaultTileName = regexp.MustCompile(`^(?:.*_)?(\d+)x(\d+)\.(?:jpe?g|png)$`)
+
+// Stitch reassembles tiles of archive made by PackImages.
+// Tile positions come from manifest.json, or from default names if there is no manifest.
+// Tiles may be edited, but must keep their size.
+func Stitch(archive *zip.Reader) (image.Image, error) {
+	set, err := ReadTileSet(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	canvas := image.Rect(0, 0, set.Width, set.Height)
+	tiles, skipped := set.Tiles, set.Skipped
+
+	// пропущенные пустые куски тоже занимают своё место
+	covered := tiles
+	for _, t := range skipped {
+		covered = append(covered, ManifestTile{
+			TileEntry: TileEntry{Row: t.Row, Col: t.Col, Index: t.Index},
+			X:         t.X, Y: t.Y, Width: t.Width, Height: t.Height,
+		})
+	}
+
+	if err := checkCoverage(canvas, covered); err != nil {
+		return nil, err
+	}
+
+	dst := image.NewNRGBA(canvas)
+
+	for _, t := range skipped {
+		r := image.Rect(t.X, t.Y, t.X+t.Width, t.Y+t.Height)
+		draw.Draw(dst, r, image.NewUniform(parseSkippedColor(t.Color)), image.Point{}, draw.Src)
+	}
+
+	for _, t := range tiles {
+		f, ok := files[t.File]
+		if !ok {
+			return nil, fmt.Errorf("%w: missing tile %q", ErrBadArchive, t.File)
+		}
+
+		// размер проверяем до декодирования, чтобы не распаковывать подменённые огромные куски
+		cfg, err := decodeZipConfig(f)
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.Width != t.Width || cfg.Height != t.Height {
+			return nil, fmt.Errorf("%w: tile %q is %dx%d px, expected %dx%d px",
+				ErrBadGrid, t.File, cfg.Width, cfg.Height, t.Width, t.Height)
+		}
+
+		tile, err := decodeZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+		r := image.Rect(t.X, t.Y, t.X+t.Width, t.Y+t.Height)
+		draw.Draw(dst, r, tile, tile.Bounds().Min, draw.Src)
+	}
+
+	return dst, nil
+}
+
+func layoutFromManifest(f *zip.File) (image.Rectangle, []ManifestTile, []SkippedTile, error) {
+	r, err := f.Open()
+	if err != nil {
+		return image.Rectangle{}, nil, nil, fmt.Errorf("unable read manifest: %w", err)
+	}
+	defer r.Close()
+
+	var m Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return image.Rectangle{}, nil, nil, fmt.Errorf("%w: bad manifest: %v", ErrBadArchive, err)
+	}
+
+	canvas := image.Rect(0, 0, m.Source.Width, m.Source.Height)
+	if m.Canvas != nil {
+		canvas = image.Rect(0, 0, m.Canvas.Width, m.Canvas.Height)
+	}
+
+	return canvas, m.Tiles, m.Skipped, nil
+}
+
+// layoutFromNames places tiles named "<name>_<row>x<col>.<ext>": every row
+// must have all columns, tiles of a row share height and tiles of a column share width.
+func layoutFromNames(zipFiles []*zip.File) (image.Rectangle, []ManifestTile, error) {
+	type cell struct{ row, col int }
+
+	grid := map[cell]*zip.File{}
+	rows, cols := 0, 0
+
+	for _, f := range zipFiles {
+		if f.FileInfo().IsDir() || f.Name == ManifestCSVFile {
+			continue
+		}
+
+		m := defaultTileName.FindStringSubmatch(path.Base(f.Name))
+		if m == nil {
+			return image.Rectangle{}, nil, fmt.Errorf("%w: unexpected file %q", ErrBadArchive, f.Name)
+		}
+
+		row, _ := strconv.Atoi(m[1])
+		col, _ := strconv.Atoi(m[2])
+
+		if row < 1 || col < 1 {
+			return image.Rectangle{}, nil, fmt.Errorf("%w: bad position of %q", ErrBadGrid, f.Name)
+		}
+
+		if _, ok := grid[cell{row, col}]; ok {
+			return image.Rectangle{}, nil, fmt.Errorf("%w: duplicate tile %dx%d", ErrBadGrid, row, col)
+		}
+
+		grid[cell{row, col}] = f
+		rows = maxInt(rows, row)
+		cols = maxInt(cols, col)
+	}
+
+	if len(grid) == 0 {
+		return image.Rectangle{}, nil, ErrEmptyGrid
+	}
+
+	if len(grid) != rows*cols {
+		return image.Rectangle{}, nil, fmt.Errorf("%w: %d tiles for %dx%d grid", ErrBadGrid, len(grid), rows, cols)
+	}
+
+	widths := make([]int, cols)
+	heights := make([]int, rows)
+
+	for c, f := range grid {
+		cfg, err := decodeZipConfig(f)
+		if err != nil {
+			return image.Rectangle{}, nil, err
+		}
+
+		w, h := &widths[c.col-1], &heights[c.row-1]
+		if (*w != 0 && *w != cfg.Width) || (*h != 0 && *h != cfg.Height) {
+			return image.Rectangle{}, nil, fmt.Errorf("%w: tile %q does not fit its row or column", ErrBadGrid, f.Name)
+		}
+		*w, *h = cfg.Width, cfg.Height
+	}
+
+	tiles := make([]ManifestTile, 0, len(grid))
+	y := 0
+
+	for row := 0; row < rows; row++ {
+		x := 0
+
+		for col := 0; col < cols; col++ {
+			f := grid[cell{row + 1, col + 1}]
+			tiles = append(tiles, ManifestTile{
+				TileEntry: TileEntry{File: f.Name, Row: row, Col: col, Index: len(tiles)},
+				X:         x, Y: y, Width: widths[col], Height: heights[row],
+			})
+			x += widths[col]
+		}
+
+		y += heights[row]
+	}
+
+	return image.Rect(0, 0, sum(widths), sum(heights)), tiles, nil
+}
+
+// checkCoverage makes sure tiles lie inside canvas, do not overlap and cover it completely.
+// Tiles without overlaps cover canvas exactly when their areas sum up to its area.
+func checkCoverage(canvas image.Rectangle, tiles []ManifestTile) error {
+	if canvas.Empty() {
+		return fmt.Errorf("%w: empty image", ErrBadGrid)
+	}
+
+	if canvas.Dx() > MaxStitchPixels || canvas.Dy() > MaxStitchPixels || canvas.Dx()*canvas.Dy() > MaxStitchPixels {
+		return fmt.Errorf("%w: %dx%d px", ErrImageTooBig, canvas.Dx(), canvas.Dy())
+	}
+
+	// каждый кусок занимает хотя бы пиксель, больше кусков быть не может
+	if len(tiles) > MaxStitchPixels {
+		return fmt.Errorf("%w: %d tiles", ErrImageTooBig, len(tiles))
+	}
+
+	area := 0
+	for _, t := range tiles {
+		r := image.Rect(t.X, t.Y, t.X+t.Width, t.Y+t.Height)
+		if r.Empty() || !r.In(canvas) {
+			return fmt.Errorf("%w: tile %q is out of image", ErrBadGrid, t.File)
+		}
+
+		area += r.Dx() * r.Dy()
+	}
+
+	if area < canvas.Dx()*canvas.Dy() {
+		return fmt.Errorf("%w: tiles do not cover image", ErrBadGrid)
+	}
+
+	return checkOverlaps(tiles)
+}
+
+// checkOverlaps sweeps tiles from left to right. Tiles crossed by the sweep line
+// do not overlap each other, so they are kept sorted by top edge and a new tile
+// is compared only with its neighbours.
+func checkOverlaps(tiles []ManifestTile) error {
+	order := make([]int, len(tiles))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.Slice(order, func(i, j int) bool { return tiles[order[i]].X < tiles[order[j]].X })
+
+	var active []int
+	sweep := -1
+
+	for _, i := range order {
+		t := tiles[i]
+
+		if t.X != sweep {
+			sweep = t.X
+
+			kept := active[:0]
+			for _, a := range active {
+				if tiles[a].X+tiles[a].Width > sweep {
+					kept = append(kept, a)
+				}
+			}
+			active = kept
+		}
+
+		pos := sort.Search(len(active), func(k int) bool { return tiles[active[k]].Y >= t.Y })
+
+		if pos < len(active) && tiles[active[pos]].Y < t.Y+t.Height {
+			return fmt.Errorf("%w: tiles %q and %q overlap", ErrBadGrid, t.File, tiles[active[pos]].File)
+		}
+
+		if prev := pos - 1; prev >= 0 && tiles[active[prev]].Y+tiles[active[prev]].Height > t.Y {
+			return fmt.Errorf("%w: tiles %q and %q overlap", ErrBadGrid, t.File, tiles[active[prev]].File)
+		}
+
+		active = append(active, 0)
+		copy(active[pos+1:], active[pos:])
+		active[pos] = i
+	}
+
+	return nil
+}
+
+func decodeZipFile(f *zip.File) (image.Image, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("unable read tile %q: %w", f.Name, err)
+	}
+	defer r.Close()
+
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable decode tile %q: %v", ErrBadArchive, f.Name, err)
+	}
+
+	return img, nil
+}
+
+func decodeZipConfig(f *zip.File) (image.Config, error) {
+	r, err := f.Open()
+	if err != nil {
+		return image.Config{}, fmt.Errorf("unable read tile %q: %w", f.Name, err)
+	}
+	defer r.Close()
+
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return image.Config{}, fmt.Errorf("%w: unable decode tile %q: %v", ErrBadArchive, f.Name, err)
+	}
+
+	return cfg, nil
+}
+
+func sum(values []int) int {
+	s := 0
+	for _, v := range values {
+		s += v
+	}
+
+	return s
+}
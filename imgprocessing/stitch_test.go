@@ -0,0 +1,171 @@
+package imgprocessing
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+// gradient is 100x70 px image, so edge tiles of 32x32 grid are smaller.
+func gradient() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 100, 70))
+	for y := 0; y < 70; y++ {
+		for x := 0; x < 100; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: uint8(x + y), A: 255})
+		}
+	}
+
+	return img
+}
+
+// packed returns archive of gradient cut into 32x32 png tiles, skip filters copied files.
+func packed(t *testing.T, naming string, skip func(name string) bool) *zip.Reader {
+	t.Helper()
+
+	img := gradient()
+	images, err := CutImage(img, 32, 32)
+	assert.Equal(t, err, nil)
+
+	nameTemplate, err := ParseNameTemplate(naming)
+	assert.Equal(t, err, nil)
+
+	buf := bytes.Buffer{}
+	zipWriter := zip.NewWriter(&buf)
+	_, err = PackImages(zipWriter, images, PackOptions{
+		Name:   "gradient",
+		Format: FormatPNG,
+		Naming: nameTemplate,
+		Source: SourceInfo{Name: "gradient.png", Width: 100, Height: 70, Format: "png"},
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, zipWriter.Close(), nil)
+
+	src, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Equal(t, err, nil)
+
+	out := bytes.Buffer{}
+	copyWriter := zip.NewWriter(&out)
+
+	for _, f := range src.File {
+		if skip(f.Name) {
+			continue
+		}
+
+		r, err := f.Open()
+		assert.Equal(t, err, nil)
+		w, err := copyWriter.Create(f.Name)
+		assert.Equal(t, err, nil)
+		_, err = io.Copy(w, r)
+		assert.Equal(t, err, nil)
+	}
+	assert.Equal(t, copyWriter.Close(), nil)
+
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	assert.Equal(t, err, nil)
+
+	return archive
+}
+
+func TestStitch(t *testing.T) {
+	testCases := []struct {
+		name    string
+		naming  string
+		skip    func(name string) bool
+		wantErr error
+	}{
+		{
+			name:   "with manifest",
+			naming: "{index0}.{ext}",
+			skip:   func(name string) bool { return false },
+		},
+		{
+			name:   "default names without manifest",
+			naming: "",
+			skip:   func(name string) bool { return name == ManifestFile },
+		},
+		{
+			name:    "missing tile",
+			naming:  "",
+			skip:    func(name string) bool { return name == ManifestFile || name == "gradient_2x3.png" },
+			wantErr: ErrBadGrid,
+		},
+		{
+			name:    "missing tile in manifest",
+			naming:  "",
+			skip:    func(name string) bool { return name == "gradient_1x1.png" },
+			wantErr: ErrBadArchive,
+		},
+		{
+			name:    "custom names without manifest",
+			naming:  "{index0}.{ext}",
+			skip:    func(name string) bool { return name == ManifestFile },
+			wantErr: ErrBadArchive,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			img, err := Stitch(packed(t, tc.naming, tc.skip))
+			assert.Equal(t, errors.Is(err, tc.wantErr), true)
+
+			if tc.wantErr != nil {
+				return
+			}
+
+			want := gradient()
+			assert.Equal(t, img.Bounds(), want.Bounds())
+
+			for y := 0; y < 70; y++ {
+				for x := 0; x < 100; x++ {
+					assert.Equal(t, color.NRGBAModel.Convert(img.At(x, y)), want.At(x, y))
+				}
+			}
+		})
+	}
+}
+
+func TestCheckCoverage(t *testing.T) {
+	tile := func(x, y, w, h int) ManifestTile {
+		return ManifestTile{X: x, Y: y, Width: w, Height: h}
+	}
+
+	canvas := image.Rect(0, 0, 10, 10)
+
+	assert.Equal(t, checkCoverage(canvas, []ManifestTile{tile(0, 0, 10, 10)}), nil)
+	assert.Equal(t, checkCoverage(canvas, []ManifestTile{tile(0, 0, 6, 10), tile(6, 0, 4, 5), tile(6, 5, 4, 5)}), nil)
+	assert.Equal(t, errors.Is(checkCoverage(canvas, []ManifestTile{tile(0, 0, 6, 10), tile(5, 0, 5, 10)}), ErrBadGrid), true)
+	assert.Equal(t, errors.Is(checkCoverage(canvas, []ManifestTile{tile(0, 0, 6, 10)}), ErrBadGrid), true)
+	assert.Equal(t, errors.Is(checkCoverage(canvas, []ManifestTile{tile(0, 0, 11, 10)}), ErrBadGrid), true)
+
+	// площадь сходится, но куски перекрываются и оставляют дыру
+	assert.Equal(t, errors.Is(checkCoverage(canvas, []ManifestTile{tile(0, 0, 5, 10), tile(4, 0, 5, 10)}), ErrBadGrid), true)
+	assert.Equal(t, errors.Is(checkCoverage(canvas, []ManifestTile{tile(0, 0, 6, 10), tile(4, 0, 5, 10)}), ErrBadGrid), true)
+	assert.Equal(t, errors.Is(checkCoverage(canvas, []ManifestTile{tile(0, 0, 10, 6), tile(3, 4, 7, 6), tile(0, 6, 3, 4)}), ErrBadGrid), true)
+	assert.Equal(t, errors.Is(checkCoverage(canvas, []ManifestTile{tile(0, 0, 10, 5), tile(0, 5, 5, 5), tile(5, 5, 5, 5), tile(2, 2, 2, 2)}), ErrBadGrid), true)
+}
+
+func TestCheckCoverage_Diagonal(t *testing.T) {
+	// тысячи кусков 1x1 по диагонали не должны выделять сетку по всем их границам
+	const n = 10_000
+
+	tiles := make([]ManifestTile, n)
+	for i := range tiles {
+		tiles[i] = ManifestTile{X: i, Y: i, Width: 1, Height: 1}
+	}
+
+	start := time.Now()
+	err := checkCoverage(image.Rect(0, 0, n, n), tiles)
+
+	assert.Equal(t, errors.Is(err, ErrImageTooBig), false)
+	assert.Equal(t, errors.Is(err, ErrBadGrid), true)
+	assert.Equal(t, time.Since(start) < time.Second, true)
+
+	assert.Equal(t, errors.Is(checkCoverage(image.Rect(0, 0, 20_000, 20_000), tiles), ErrImageTooBig), true)
+}
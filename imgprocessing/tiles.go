@@ -0,0 +1,74 @@
+package imgprocessing
+
+import (
+	"archive/zip"
+	"fmt"
+	"image"
+)
+
+// TileSet is layout of tiles of archive made by PackImages.
+type TileSet struct {
+	Width   int            `json:"width"` // of image tiles cover
+	Height  int            `json:"height"`
+	Tiles   []ManifestTile `json:"tiles"`
+	Skipped []SkippedTile  `json:"skipped,omitempty"`
+}
+
+// ReadTileSet lists tiles of archive. Layout comes from manifest.json,
+// or from default names if there is no manifest. Files of tiles are not checked.
+func ReadTileSet(archive *zip.Reader) (*TileSet, error) {
+	var (
+		canvas image.Rectangle
+		set    TileSet
+		err    error
+	)
+
+	if f := findZipFile(archive, ManifestFile); f != nil {
+		canvas, set.Tiles, set.Skipped, err = layoutFromManifest(f)
+	} else {
+		canvas, set.Tiles, err = layoutFromNames(archive.File)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	set.Width, set.Height = canvas.Dx(), canvas.Dy()
+
+	return &set, nil
+}
+
+// Tile finds tile stored in file. Deduplicated tiles share file, the first one is returned.
+func (ts *TileSet) Tile(file string) (ManifestTile, bool) {
+	for _, t := range ts.Tiles {
+		if t.File == file {
+			return t, true
+		}
+	}
+
+	return ManifestTile{}, false
+}
+
+// OpenTile opens file of tile listed in set, other files of archive are not available.
+func OpenTile(archive *zip.Reader, set *TileSet, file string) (*zip.File, error) {
+	if _, ok := set.Tile(file); !ok {
+		return nil, fmt.Errorf("%w: no tile %q", ErrBadArchive, file)
+	}
+
+	f := findZipFile(archive, file)
+	if f == nil {
+		return nil, fmt.Errorf("%w: missing tile %q", ErrBadArchive, file)
+	}
+
+	return f, nil
+}
+
+func findZipFile(archive *zip.Reader, name string) *zip.File {
+	for _, f := range archive.File {
+		if f.Name == name {
+			return f
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package imgprocessing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestReadTileSet(t *testing.T) {
+	tests := []struct {
+		name   string
+		naming string
+		skip   func(name string) bool
+	}{
+		{name: "manifest", naming: "{row}-{col}.{ext}", skip: func(name string) bool { return false }},
+		{name: "default names", naming: DefaultNameTemplate, skip: func(name string) bool { return name == ManifestFile }},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			archive := packed(t, tc.naming, tc.skip)
+
+			set, err := ReadTileSet(archive)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, set.Width, 100)
+			assert.Equal(t, set.Height, 70)
+			assert.Equal(t, len(set.Tiles), 4*3)
+
+			last := set.Tiles[len(set.Tiles)-1]
+			assert.Equal(t, [4]int{last.X, last.Y, last.Width, last.Height}, [4]int{96, 64, 4, 6})
+
+			f, err := OpenTile(archive, set, last.File)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, f.Name, last.File)
+
+			_, err = OpenTile(archive, set, ManifestFile)
+			assert.Equal(t, errors.Is(err, ErrBadArchive), true)
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package imgprocessing
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrBadCrop      = errors.New("bad crop rectangle")
+	ErrBadRotate    = errors.New("rotation must be 0, 90, 180 or 270 degrees")
+	ErrUnknownFlip  = errors.New("unknown flip")
+	ErrBadScale     = errors.New("bad scale")
+	ErrBadTransform = errors.New("transformed image is too big")
+)
+
+// MaxScale limits upscaling of source by Transform.
+const MaxScale = 8
+
+// Flip mirrors image.
+type Flip string
+
+const (
+	FlipNone       Flip = ""
+	FlipHorizontal Flip = "horizontal" // left and right are swapped
+	FlipVertical   Flip = "vertical"   // top and bottom are swapped
+)
+
+func ParseFlip(s string) (Flip, error) {
+	switch Flip(s) {
+	case FlipNone, FlipHorizontal, FlipVertical:
+		return Flip(s), nil
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrUnknownFlip, s)
+}
+
+// Rect is a rectangle relative to top left corner of image.
+type Rect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// ParseRect accepts "x,y,width,height", empty string gives nil.
+func ParseRect(s string) (*Rect, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("%w: %q, want x,y,width,height", ErrBadCrop, s)
+	}
+
+	v := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrBadCrop, s)
+		}
+		v[i] = n
+	}
+
+	r := &Rect{X: v[0], Y: v[1], Width: v[2], Height: v[3]}
+	if r.X < 0 || r.Y < 0 || r.Width < 1 || r.Height < 1 {
+		return nil, fmt.Errorf("%w: %q", ErrBadCrop, s)
+	}
+
+	return r, nil
+}
+
+func (r Rect) String() string {
+	return fmt.Sprintf("%d,%d,%d,%d", r.X, r.Y, r.Width, r.Height)
+}
+
+// Transform is applied to source image before it is cut, steps go in order of fields.
+type Transform struct {
+	Crop   *Rect   `json:"crop,omitempty"`   // nil keeps whole image
+	Rotate int     `json:"rotate,omitempty"` // clockwise degrees
+	Flip   Flip    `json:"flip,omitempty"`
+	Scale  float64 `json:"scale,omitempty"`  // 0 and 1 keep size
+	Filter Filter  `json:"filter,omitempty"` // used by Scale, FilterCatmullRom if empty
+}
+
+func (t Transform) Validate() error {
+	if t.Crop != nil && (t.Crop.X < 0 || t.Crop.Y < 0 || t.Crop.Width < 1 || t.Crop.Height < 1) {
+		return fmt.Errorf("%w: %s", ErrBadCrop, t.Crop)
+	}
+
+	if t.Rotate != 0 && t.Rotate != 90 && t.Rotate != 180 && t.Rotate != 270 {
+		return fmt.Errorf("%w: %d", ErrBadRotate, t.Rotate)
+	}
+
+	if _, err := ParseFlip(string(t.Flip)); err != nil {
+		return err
+	}
+
+	if t.Scale < 0 || t.Scale > MaxScale || math.IsNaN(t.Scale) {
+		return fmt.Errorf("%w: %v, want 0..%d", ErrBadScale, t.Scale, MaxScale)
+	}
+
+	_, err := ParseFilter(string(t.Filter))
+
+	return err
+}
+
+// IsIdentity tells that Transform does not change image.
+func (t Transform) IsIdentity() bool {
+	return t.Crop == nil && t.Rotate == 0 && t.Flip == FlipNone && (t.Scale == 0 || t.Scale == 1)
+}
+
+// ApplyTransform returns img cropped, rotated, flipped and scaled, result has zero origin.
+// img itself is returned if Transform is identity.
+func ApplyTransform(img image.Image, t Transform) (image.Image, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	if t.IsIdentity() {
+		return img, nil
+	}
+
+	b := img.Bounds()
+
+	if t.Crop != nil {
+		r := image.Rect(t.Crop.X, t.Crop.Y, t.Crop.X+t.Crop.Width, t.Crop.Y+t.Crop.Height).Add(b.Min)
+		if !r.In(b) {
+			return nil, fmt.Errorf("%w: %s is out of %dx%d image", ErrBadCrop, t.Crop, b.Dx(), b.Dy())
+		}
+
+		subImager, err := castSubImager(img)
+		if err != nil {
+			return nil, err
+		}
+
+		img = subImager.SubImage(r)
+	}
+
+	src := toNRGBA(img)
+
+	switch t.Rotate {
+	case 90, 270:
+		src = rotate(src, t.Rotate)
+	case 180:
+		src = flip(flip(src, FlipHorizontal), FlipVertical)
+	}
+
+	if t.Flip != FlipNone {
+		src = flip(src, t.Flip)
+	}
+
+	if t.Scale != 0 && t.Scale != 1 {
+		size := scaled(src.Bounds().Size(), t.Scale)
+		if int64(size.X)*int64(size.Y) > MaxStitchPixels {
+			return nil, fmt.Errorf("%w: %dx%d px", ErrBadTransform, size.X, size.Y)
+		}
+
+		filter, _ := ParseFilter(string(t.Filter))
+		src = Resize(src, size, filter)
+	}
+
+	return src, nil
+}
+
+// rotate turns src clockwise by 90 or 270 degrees.
+func rotate(src *image.NRGBA, degrees int) *image.NRGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, h, w))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := h-1-y, x
+			if degrees == 270 {
+				dx, dy = y, w-1-x
+			}
+
+			copy(dst.Pix[dst.PixOffset(dx, dy):][:4], src.Pix[src.PixOffset(x, y):][:4])
+		}
+	}
+
+	return dst
+}
+
+// flip returns mirrored copy of src, src is not changed.
+func flip(src *image.NRGBA, f Flip) *image.NRGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := w-1-x, y
+			if f == FlipVertical {
+				dx, dy = x, h-1-y
+			}
+
+			copy(dst.Pix[dst.PixOffset(dx, dy):][:4], src.Pix[src.PixOffset(x, y):][:4])
+		}
+	}
+
+	return dst
+}
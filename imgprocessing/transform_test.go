@@ -0,0 +1,100 @@
+package imgprocessing
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestApplyTransform(t *testing.T) {
+	// 3x2 image with a marked top left corner
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	mark := color.NRGBA{R: 255, A: 255}
+	src.SetNRGBA(0, 0, mark)
+
+	tests := []struct {
+		name      string
+		transform Transform
+		size      image.Point
+		mark      image.Point // new position of marked pixel
+	}{
+		{name: "identity", transform: Transform{Scale: 1}, size: image.Pt(3, 2), mark: image.Pt(0, 0)},
+		{name: "rotate 90", transform: Transform{Rotate: 90}, size: image.Pt(2, 3), mark: image.Pt(1, 0)},
+		{name: "rotate 180", transform: Transform{Rotate: 180}, size: image.Pt(3, 2), mark: image.Pt(2, 1)},
+		{name: "rotate 270", transform: Transform{Rotate: 270}, size: image.Pt(2, 3), mark: image.Pt(0, 2)},
+		{name: "flip horizontal", transform: Transform{Flip: FlipHorizontal}, size: image.Pt(3, 2), mark: image.Pt(2, 0)},
+		{name: "flip vertical", transform: Transform{Flip: FlipVertical}, size: image.Pt(3, 2), mark: image.Pt(0, 1)},
+		{name: "rotate then flip", transform: Transform{Rotate: 90, Flip: FlipHorizontal}, size: image.Pt(2, 3), mark: image.Pt(0, 0)},
+		{name: "crop", transform: Transform{Crop: &Rect{X: 0, Y: 0, Width: 2, Height: 1}}, size: image.Pt(2, 1), mark: image.Pt(0, 0)},
+		{name: "scale", transform: Transform{Scale: 2, Filter: FilterNearest}, size: image.Pt(6, 4), mark: image.Pt(1, 1)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dst, err := ApplyTransform(src, tc.transform)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, dst.Bounds().Size(), tc.size)
+			assert.Equal(t, color.NRGBAModel.Convert(dst.At(tc.mark.X, tc.mark.Y)), mark)
+		})
+	}
+
+	for _, tr := range []Transform{
+		{Crop: &Rect{X: 2, Y: 0, Width: 2, Height: 2}},
+		{Rotate: 45},
+		{Flip: "diagonal"},
+		{Scale: -1},
+		{Scale: MaxScale + 1},
+	} {
+		_, err := ApplyTransform(src, tr)
+		assert.Equal(t, err != nil, true)
+	}
+}
+
+func TestParseRect(t *testing.T) {
+	r, err := ParseRect(" 1, 2,30,40")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, *r, Rect{X: 1, Y: 2, Width: 30, Height: 40})
+
+	r, err = ParseRect("")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, r == nil, true)
+
+	for _, s := range []string{"1,2,3", "a,b,c,d", "0,0,0,10", "-1,0,10,10"} {
+		_, err = ParseRect(s)
+		assert.Equal(t, err != nil, true, s)
+	}
+}
+
+func TestStitch_Transformed(t *testing.T) {
+	transform := Transform{Crop: &Rect{X: 10, Y: 5, Width: 80, Height: 60}, Rotate: 90}
+	img, err := ApplyTransform(gradient(), transform)
+	assert.Equal(t, err, nil)
+
+	images, err := CutImage(img, 32, 32)
+	assert.Equal(t, err, nil)
+
+	buf := bytes.Buffer{}
+	zipWriter := zip.NewWriter(&buf)
+	manifest, err := PackImages(zipWriter, images, PackOptions{
+		Name:      "pic",
+		Format:    FormatPNG,
+		Source:    SourceInfo{Name: "pic.png", Width: 100, Height: 70},
+		Transform: &transform,
+		Canvas:    img.Bounds().Size(),
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, zipWriter.Close(), nil)
+	assert.Equal(t, *manifest.Canvas, CanvasSize{Width: 60, Height: 80})
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Equal(t, err, nil)
+
+	stitched, err := Stitch(archive)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, stitched.Bounds(), img.Bounds())
+	assert.Equal(t, stitched.At(59, 0), img.At(59, 0))
+}
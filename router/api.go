@@ -0,0 +1,273 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"imgcutter/imgprocessing"
+	"imgcutter/service"
+)
+
+// maxUploadSize limits multipart form of /api/files kept in memory, the rest goes to temp files.
+const maxUploadSize = 32 << 20
+
+// apiFile is uploaded file as seen by the frontend.
+type apiFile struct {
+	Name     string                  `json:"name"` // identifies file in other requests
+	BaseName string                  `json:"baseName"`
+	Info     imgprocessing.ImageInfo `json:"info"`
+	Size     int64                   `json:"size"`
+	Uploaded time.Time               `json:"uploaded"`
+	Cut      bool                    `json:"cut"`   // archive is ready to download
+	Tiles    bool                    `json:"tiles"` // archive can be shown in gallery
+}
+
+func newAPIFile(f service.MyFile) apiFile {
+	return apiFile{
+		Name:     f.OriginalFile,
+		BaseName: filepath.Base(f.OriginalFile),
+		Info:     f.Info,
+		Size:     f.Size,
+		Uploaded: f.Uploaded,
+		Cut:      f.Archive != "",
+		Tiles:    f.Tiles,
+	}
+}
+
+// apiCSRF is token frontend sends in X-CSRF-Token header of state-changing requests.
+type apiCSRF struct {
+	Token string `json:"token"`
+}
+
+// apiMessages is catalog of locale of client, frontend translates itself with it.
+type apiMessages struct {
+	Locale   string            `json:"locale"`
+	Messages map[string]string `json:"messages"`
+}
+
+// apiError is body of every failed api request.
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("error encoding json: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+}
+
+func (h *Handler) writeFiles(w http.ResponseWriter, r *http.Request, s *service.Session, status int) {
+	files, err := h.service.Files.GetFiles(s)
+	if err != nil {
+		h.writeError(w, r, fmt.Errorf("unable to get files list: %w", err))
+		return
+	}
+
+	out := make([]apiFile, 0, len(files))
+	for _, f := range files {
+		out = append(out, newAPIFile(f))
+	}
+
+	writeJSON(w, status, out)
+}
+
+// APIFiles lists files of session on GET, uploads multipart "files" on POST
+// and deletes "fileName" of query on DELETE. GET and POST answer with list of files.
+func (h *Handler) APIFiles(w http.ResponseWriter, r *http.Request) {
+	s, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.writeFiles(w, r, s, http.StatusOK)
+
+	case http.MethodPost:
+		if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+			h.writeError(w, r, badRequest("error parsing form", err))
+			return
+		}
+
+		headers := r.MultipartForm.File["files"]
+		if len(headers) == 0 {
+			h.writeError(w, r, h.missingField(r, "files"))
+			return
+		}
+
+		for _, fileHeader := range headers {
+			contentType := fileHeader.Header.Get("content-type")
+			if !(contentType == "image/jpeg" || contentType == "image/png") {
+				log.Printf("invalid fileHeader content-type: %s", contentType)
+				h.writeError(w, r, withStatus(http.StatusUnsupportedMediaType, errors.New(fileHeader.Filename+": "+h.t(r, "error.not_image"))))
+
+				return
+			}
+
+			f, err := fileHeader.Open()
+			if err != nil {
+				h.writeError(w, r, badRequest("error opening uploaded file", err))
+				return
+			}
+
+			err = h.service.Files.UploadFile(s, f, filepath.Base(fileHeader.Filename))
+			f.Close()
+
+			if err != nil {
+				h.writeError(w, r, fmt.Errorf("%s: %w", fileHeader.Filename, err))
+				return
+			}
+
+			log.Printf("file %s succsesfully uploaded", fileHeader.Filename)
+		}
+
+		h.writeFiles(w, r, s, http.StatusCreated)
+
+	case http.MethodDelete:
+		fileName := r.URL.Query().Get("fileName")
+		if err := h.service.Files.DeleteFile(s, fileName); err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		h.writeError(w, r, withStatus(http.StatusMethodNotAllowed, errors.New(h.t(r, "error.method_not_allowed"))))
+	}
+}
+
+// APICut cuts "fileName" of form with params of form or "preset", answers with the cut file.
+func (h *Handler) APICut(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		h.writeError(w, r, withStatus(http.StatusMethodNotAllowed, errors.New(h.t(r, "error.method_not_allowed"))))
+
+		return
+	}
+
+	if err := r.ParseMultipartForm(maxRegionsSpecSize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		h.writeError(w, r, badRequest("error parsing form", err))
+		return
+	}
+
+	fileName := r.PostForm.Get("fileName")
+	if fileName == "" {
+		h.writeError(w, r, h.missingField(r, "fileName"))
+		return
+	}
+
+	s, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	var params service.CutParams
+
+	if presetName := r.PostForm.Get("preset"); presetName != "" {
+		preset, err := h.service.Presets.FindPreset(s, presetName)
+		if err != nil {
+			h.writeError(w, r, fmt.Errorf("error finding preset %q: %w", presetName, err))
+			return
+		}
+		params = preset.CutParams
+	} else {
+		p, err := parseCutParams(r.PostForm)
+		if err != nil {
+			h.writeError(w, r, badRequest("error parsing cut params", err))
+			return
+		}
+		params = p
+	}
+
+	if err := h.service.Files.CutFile(s, fileName, params); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	files, err := h.service.Files.GetFiles(s)
+	if err != nil {
+		h.writeError(w, r, fmt.Errorf("unable to get files list: %w", err))
+		return
+	}
+
+	for _, f := range files {
+		if f.OriginalFile == fileName {
+			writeJSON(w, http.StatusOK, newAPIFile(f))
+			return
+		}
+	}
+
+	h.writeError(w, r, service.ErrFileNotFound)
+}
+
+// APICSRF gives frontend token of session.
+func (h *Handler) APICSRF(w http.ResponseWriter, r *http.Request) {
+	s, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, apiCSRF{Token: s.CSRFToken()})
+}
+
+// APIMessages answers with messages of locale chosen by Localize.
+func (h *Handler) APIMessages(w http.ResponseWriter, r *http.Request) {
+	locale := h.locale(r)
+	writeJSON(w, http.StatusOK, apiMessages{Locale: locale, Messages: messages.Catalog(locale)})
+}
+
+// APIPresets lists presets of session.
+func (h *Handler) APIPresets(w http.ResponseWriter, r *http.Request) {
+	s, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	presets, err := h.service.Presets.GetPresets(s)
+	if err != nil {
+		h.writeError(w, r, fmt.Errorf("unable to get presets list: %w", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, presets)
+}
+
+// APIArchive serves archive of cut "fileName" of query.
+func (h *Handler) APIArchive(w http.ResponseWriter, r *http.Request) {
+	s, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	archiveName, err := h.service.Files.GetArchiveName(s, r.URL.Query().Get("fileName"))
+	if err != nil {
+		h.writeError(w, r, fmt.Errorf("error getting archive name: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(filepath.Base(archiveName)))
+	w.Header().Set("Content-Type", "application/zip")
+	http.ServeFile(w, r, archiveName)
+}
@@ -0,0 +1,299 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"imgcutter/imgprocessing"
+	"imgcutter/service"
+	"imgcutter/static"
+
+	"github.com/golang/mock/gomock"
+	"github.com/magiconair/properties/assert"
+)
+
+func uploadForm(t *testing.T, contentType string) (*bytes.Buffer, string) {
+	buf := bytes.Buffer{}
+	multipartWriter := multipart.NewWriter(&buf)
+
+	for _, name := range []string{"a.png", "b.png"} {
+		header := make(textproto.MIMEHeader)
+		header.Set("content-disposition", fmt.Sprintf(`form-data; name="files"; filename=%q`, name))
+		header.Set("content-type", contentType)
+
+		partWriter, err := multipartWriter.CreatePart(header)
+		assert.Equal(t, err, nil)
+		_, err = partWriter.Write([]byte("png"))
+		assert.Equal(t, err, nil)
+	}
+
+	assert.Equal(t, multipartWriter.Close(), nil)
+
+	return &buf, multipartWriter.FormDataContentType()
+}
+
+func TestRouter_APIFiles(t *testing.T) {
+	files := []service.MyFile{{OriginalFile: "temp/id/a.png", Archive: "temp/id/a.zip", Info: imgprocessing.ImageInfo{Width: 10}}}
+
+	testCases := []struct {
+		name                 string
+		request              func(t *testing.T) *http.Request
+		fileServiceBehaviour func(mfs *service.MockFileService, session *service.Session)
+		responseCode         int
+		files                int
+	}{
+		{
+			name: "list",
+			request: func(t *testing.T) *http.Request {
+				r, _ := http.NewRequest(http.MethodGet, "/api/files", nil)
+				return r
+			},
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().GetFiles(session).Return(files, nil)
+			},
+			responseCode: http.StatusOK,
+			files:        1,
+		},
+		{
+			name: "upload",
+			request: func(t *testing.T) *http.Request {
+				body, contentType := uploadForm(t, "image/png")
+				r, _ := http.NewRequest(http.MethodPost, "/api/files", body)
+				r.Header.Set("Content-Type", contentType)
+				return r
+			},
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().UploadFile(session, gomock.Any(), "a.png").Return(nil)
+				mfs.EXPECT().UploadFile(session, gomock.Any(), "b.png").Return(nil)
+				mfs.EXPECT().GetFiles(session).Return(files, nil)
+			},
+			responseCode: http.StatusCreated,
+			files:        1,
+		},
+		{
+			name: "upload not an image",
+			request: func(t *testing.T) *http.Request {
+				body, contentType := uploadForm(t, "image/png")
+				r, _ := http.NewRequest(http.MethodPost, "/api/files", body)
+				r.Header.Set("Content-Type", contentType)
+				return r
+			},
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().UploadFile(session, gomock.Any(), "a.png").Return(service.ErrNotImage)
+			},
+			responseCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name: "upload wrong content type",
+			request: func(t *testing.T) *http.Request {
+				body, contentType := uploadForm(t, "text/plain")
+				r, _ := http.NewRequest(http.MethodPost, "/api/files", body)
+				r.Header.Set("Content-Type", contentType)
+				return r
+			},
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {},
+			responseCode:         http.StatusUnsupportedMediaType,
+		},
+		{
+			name: "delete",
+			request: func(t *testing.T) *http.Request {
+				r, _ := http.NewRequest(http.MethodDelete, "/api/files?fileName=temp%2Fid%2Fa.png", nil)
+				return r
+			},
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().DeleteFile(session, "temp/id/a.png").Return(nil)
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "delete not found",
+			request: func(t *testing.T) *http.Request {
+				r, _ := http.NewRequest(http.MethodDelete, "/api/files?fileName=b.png", nil)
+				return r
+			},
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().DeleteFile(session, "b.png").Return(service.ErrFileNotFound)
+			},
+			responseCode: http.StatusNotFound,
+		},
+		{
+			name: "method not allowed",
+			request: func(t *testing.T) *http.Request {
+				r, _ := http.NewRequest(http.MethodPut, "/api/files", nil)
+				return r
+			},
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {},
+			responseCode:         http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			ss := service.NewMockSessionService(c)
+			fs := service.NewMockFileService(c)
+			handler := Handler{
+				service: service.Service{Files: fs, Session: ss},
+			}
+
+			ss.EXPECT().Find("some-session-id").Return(&service.Session{}, true)
+			tc.fileServiceBehaviour(fs, &service.Session{})
+
+			r := tc.request(t).WithContext(context.WithValue(context.Background(), ctxSessionKey, "some-session-id"))
+
+			w := httptest.NewRecorder()
+			handler.APIFiles(w, r)
+			assert.Equal(t, w.Result().StatusCode, tc.responseCode)
+			assert.Equal(t, strings.HasPrefix(w.Result().Header.Get("Content-Type"), "application/json"), tc.responseCode != http.StatusNoContent)
+
+			if tc.files > 0 {
+				var got []apiFile
+				assert.Equal(t, json.NewDecoder(w.Body).Decode(&got), nil)
+				assert.Equal(t, len(got), tc.files)
+				assert.Equal(t, got[0].BaseName, "a.png")
+				assert.Equal(t, got[0].Cut, true)
+			}
+		})
+	}
+}
+
+func TestRouter_APICut(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		form                 url.Values
+		fileServiceBehaviour func(mfs *service.MockFileService, session *service.Session)
+		responseCode         int
+	}{
+		{
+			name: "ok",
+			form: url.Values{"fileName": {"temp/id/a.png"}, "dX": {"32"}, "dY": {"32"}},
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().CutFile(session, "temp/id/a.png", gomock.Any()).Return(nil)
+				mfs.EXPECT().GetFiles(session).Return([]service.MyFile{{OriginalFile: "temp/id/a.png", Archive: "temp/id/a.zip"}}, nil)
+			},
+			responseCode: http.StatusOK,
+		},
+		{
+			name:                 "bad params",
+			form:                 url.Values{"fileName": {"temp/id/a.png"}, "dX": {"many"}},
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {},
+			responseCode:         http.StatusBadRequest,
+		},
+		{
+			name: "cut error",
+			form: url.Values{"fileName": {"temp/id/a.png"}, "dX": {"32"}, "dY": {"32"}},
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().CutFile(session, "temp/id/a.png", gomock.Any()).
+					Return(fmt.Errorf("error on cut img: %w", imgprocessing.ErrSmallCut))
+			},
+			responseCode: http.StatusBadRequest,
+		},
+		{
+			name: "file system error",
+			form: url.Values{"fileName": {"temp/id/a.png"}, "dX": {"32"}, "dY": {"32"}},
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().CutFile(session, "temp/id/a.png", gomock.Any()).Return(service.ErrFS)
+			},
+			responseCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			ss := service.NewMockSessionService(c)
+			fs := service.NewMockFileService(c)
+			handler := Handler{
+				service: service.Service{Files: fs, Session: ss},
+			}
+
+			ss.EXPECT().Find("some-session-id").Return(&service.Session{}, true)
+			tc.fileServiceBehaviour(fs, &service.Session{})
+
+			r, _ := http.NewRequest(http.MethodPost, "/api/cut", strings.NewReader(tc.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r = r.WithContext(context.WithValue(context.Background(), ctxSessionKey, "some-session-id"))
+
+			w := httptest.NewRecorder()
+			handler.APICut(w, r)
+			assert.Equal(t, w.Result().StatusCode, tc.responseCode)
+		})
+	}
+}
+
+func TestRouter_APICut_UnknownFile(t *testing.T) {
+	svc := service.NewService(nil)
+	s := svc.Session.New()
+	handler := Handler{service: svc}
+
+	for _, name := range []string{"temp/" + s.String() + "/a.png", "../service/mem.jpg", "temp/" + s.String() + "/../../go.mod"} {
+		t.Run(name, func(t *testing.T) {
+			form := url.Values{"fileName": {name}, "dX": {"32"}, "dY": {"32"}}
+			r, _ := http.NewRequest(http.MethodPost, "/api/cut", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r = r.WithContext(context.WithValue(context.Background(), ctxSessionKey, s.String()))
+
+			w := httptest.NewRecorder()
+			handler.APICut(w, r)
+			assert.Equal(t, w.Result().StatusCode, http.StatusNotFound)
+
+			// рядом с чужим файлом не появляется архив
+			_, err := os.Stat("../service/mem.zip")
+			assert.Equal(t, errors.Is(err, fs.ErrNotExist), true)
+		})
+	}
+}
+
+func TestRouter_App(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	app, err := fs.Sub(static.App, "app")
+	assert.Equal(t, err, nil)
+
+	ss := service.NewMockSessionService(c)
+	handler := Handler{
+		service: service.Service{Session: ss},
+		app:     app,
+	}
+
+	ss.EXPECT().New().Return(&service.Session{}).Times(2)
+
+	for _, path := range []string{"/app/", "/app/app.js"} {
+		r, _ := http.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		handler.GetHTTPHandler().ServeHTTP(w, r)
+		assert.Equal(t, w.Result().StatusCode, http.StatusOK)
+	}
+}
+
+func TestRouter_APIMessages(t *testing.T) {
+	handler := Handler{}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/messages", nil)
+	r = r.WithContext(context.WithValue(r.Context(), ctxLocaleKey, "ru"))
+
+	w := httptest.NewRecorder()
+	handler.APIMessages(w, r)
+	assert.Equal(t, w.Result().StatusCode, http.StatusOK)
+
+	var body apiMessages
+	assert.Equal(t, json.NewDecoder(w.Body).Decode(&body), nil)
+	assert.Equal(t, body.Locale, "ru")
+	assert.Equal(t, body.Messages["app.files"], "Файлы")
+	assert.Equal(t, body.Messages["common.delete"], messages.T("ru", "common.delete"))
+}
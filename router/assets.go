@@ -0,0 +1,121 @@
+package router
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// assetsCacheControl lets browsers reuse assets for an hour, then revalidate them by ETag.
+// Pages are revalidated every time, they link assets by the same names after upgrade.
+const (
+	assetsCacheControl = "public, max-age=3600"
+	pagesCacheControl  = "no-cache"
+)
+
+// overlayFS looks for files in upper first, so files of custom branding replace built-in ones.
+type overlayFS struct {
+	upper, lower fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.upper.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.lower.Open(name)
+	}
+
+	return f, err
+}
+
+// ReadDir merges both directories, so globs see built-in and custom files together.
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	lower, lowerErr := fs.ReadDir(o.lower, name)
+	if lowerErr != nil && !errors.Is(lowerErr, fs.ErrNotExist) {
+		return nil, lowerErr
+	}
+
+	upper, upperErr := fs.ReadDir(o.upper, name)
+	if upperErr != nil && !errors.Is(upperErr, fs.ErrNotExist) {
+		return nil, upperErr
+	}
+
+	if lowerErr != nil && upperErr != nil {
+		return nil, lowerErr
+	}
+
+	merged := make(map[string]fs.DirEntry, len(lower)+len(upper))
+	for _, e := range lower {
+		merged[e.Name()] = e
+	}
+	for _, e := range upper {
+		merged[e.Name()] = e
+	}
+
+	entries := make([]fs.DirEntry, 0, len(merged))
+	for _, e := range merged {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	return entries, nil
+}
+
+// loadFS returns subdirectory dir of embedded fsys with the same subdirectory of overrideDir over it.
+func loadFS(fsys fs.FS, dir string, overrideDir string) (fs.FS, error) {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if overrideDir == "" {
+		return sub, nil
+	}
+
+	return overlayFS{upper: os.DirFS(filepath.Join(overrideDir, dir)), lower: sub}, nil
+}
+
+// serveFiles serves files of fsys by path of request, "index.html" for directories.
+// Responses carry ETag of content, so unchanged files are answered with 304 Not Modified.
+func serveFiles(fsys fs.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" || strings.HasSuffix(r.URL.Path, "/") {
+			name = path.Join(name, "index.html")
+		}
+
+		info, err := fs.Stat(fsys, name)
+		if err != nil || info.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			log.Printf("error reading asset %s: %v", name, err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		sum := sha256.Sum256(data)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+
+		if path.Ext(name) == ".html" {
+			w.Header().Set("Cache-Control", pagesCacheControl)
+		} else {
+			w.Header().Set("Cache-Control", assetsCacheControl)
+		}
+
+		// ServeContent answers conditional and range requests by ETag itself
+		http.ServeContent(w, r, name, info.ModTime(), bytes.NewReader(data))
+	}
+}
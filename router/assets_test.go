@@ -0,0 +1,124 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"imgcutter/service"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestOverlayFS(t *testing.T) {
+	o := overlayFS{
+		upper: fstest.MapFS{"logo.png": {Data: []byte("custom")}, "custom.css": {Data: []byte("a{}")}},
+		lower: fstest.MapFS{"logo.png": {Data: []byte("built-in")}, "app.js": {Data: []byte("js")}},
+	}
+
+	data, err := fs.ReadFile(o, "logo.png")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, string(data), "custom")
+
+	data, err = fs.ReadFile(o, "app.js")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, string(data), "js")
+
+	_, err = fs.ReadFile(o, "missing.js")
+	assert.Equal(t, errors.Is(err, fs.ErrNotExist), true)
+
+	names, err := fs.Glob(o, "*")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, names, []string{"app.js", "custom.css", "logo.png"})
+
+	// каталога переопределения может и не быть
+	o.upper = os.DirFS(filepath.Join(t.TempDir(), "missing"))
+	names, err = fs.Glob(o, "*")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, names, []string{"app.js", "logo.png"})
+}
+
+func TestServeFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":         {Data: []byte("console.log(1)")},
+		"index.html":     {Data: []byte("<html></html>")},
+		"dir/index.html": {Data: []byte("<p>dir</p>")},
+	}
+
+	testCases := []struct {
+		name         string
+		url          string
+		ifNoneMatch  bool
+		responseCode int
+		body         string
+		cacheControl string
+	}{
+		{name: "script", url: "/app.js", responseCode: http.StatusOK, body: "console.log(1)", cacheControl: assetsCacheControl},
+		{name: "not modified", url: "/app.js", ifNoneMatch: true, responseCode: http.StatusNotModified, cacheControl: assetsCacheControl},
+		{name: "index", url: "/", responseCode: http.StatusOK, body: "<html></html>", cacheControl: pagesCacheControl},
+		{name: "index of dir", url: "/dir/", responseCode: http.StatusOK, body: "<p>dir</p>", cacheControl: pagesCacheControl},
+		{name: "dir", url: "/dir", responseCode: http.StatusNotFound},
+		{name: "missing", url: "/missing.css", responseCode: http.StatusNotFound},
+		{name: "outside", url: "/../app.js", responseCode: http.StatusOK, body: "console.log(1)", cacheControl: assetsCacheControl},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			serve := serveFiles(fsys)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.URL.Path = tc.url
+			if tc.ifNoneMatch {
+				w := httptest.NewRecorder()
+				serve(w, r)
+				r.Header.Set("If-None-Match", w.Result().Header.Get("ETag"))
+			}
+
+			w := httptest.NewRecorder()
+			serve(w, r)
+			assert.Equal(t, w.Result().StatusCode, tc.responseCode)
+			assert.Equal(t, w.Result().Header.Get("Cache-Control"), tc.cacheControl)
+
+			if tc.responseCode == http.StatusOK {
+				assert.Equal(t, w.Body.String(), tc.body)
+				assert.Equal(t, len(w.Result().Header.Get("ETag")), 34)
+			}
+		})
+	}
+}
+
+// Router must not depend on working directory, custom files replace built-in ones.
+func TestNewRouter_OverrideDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, os.MkdirAll(filepath.Join(dir, "templates"), 0o755), nil)
+	assert.Equal(t, os.MkdirAll(filepath.Join(dir, "assets"), 0o755), nil)
+	assert.Equal(t, os.WriteFile(filepath.Join(dir, "templates", "error.html"), []byte(`<p>{{t "error.title" .Status}}, custom</p>`), 0o644), nil)
+	assert.Equal(t, os.WriteFile(filepath.Join(dir, "assets", "favicon.ico"), []byte("custom icon"), 0o644), nil)
+
+	for _, opts := range []Options{{}, {OverrideDir: dir}} {
+		h, err := NewRouter(service.Service{}, opts)
+		assert.Equal(t, err, nil)
+
+		b := bytes.Buffer{}
+		assert.Equal(t, h.templates.ExecuteTemplate(&b, "en", "error.html", errorPage{Status: http.StatusNotFound}), nil)
+		assert.Equal(t, bytes.Contains(b.Bytes(), []byte("custom")), opts.OverrideDir != "")
+
+		// встроенный шаблон не переопределён
+		assert.Equal(t, h.templates.ExecuteTemplate(&bytes.Buffer{}, "en", "gallery.html", newGalleryPage("a.png", testTileSet())), nil)
+
+		w := httptest.NewRecorder()
+		serveFiles(h.assets)(w, httptest.NewRequest(http.MethodGet, "/favicon.ico", nil))
+		assert.Equal(t, w.Result().StatusCode, http.StatusOK)
+		assert.Equal(t, w.Body.String() == "custom icon", opts.OverrideDir != "")
+
+		w = httptest.NewRecorder()
+		serveFiles(h.assets)(w, httptest.NewRequest(http.MethodGet, "/preview.js", nil))
+		assert.Equal(t, w.Result().StatusCode, http.StatusOK)
+		assert.Equal(t, w.Result().Header.Get("Content-Type"), "text/javascript; charset=utf-8")
+	}
+}
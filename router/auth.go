@@ -1,28 +1,29 @@
 package router
 
 import (
-	"log"
+	"fmt"
 	"net/http"
 )
 
 func (h *Handler) TerminateSession(w http.ResponseWriter, r *http.Request) {
-	sessionID, ok := r.Context().Value(ctxSessionKey).(string)
-	if !ok {
-		log.Printf("unable to get context value")
-		w.WriteHeader(http.StatusInternalServerError)
+	if r.Method != http.MethodPost {
+		h.redirect(w, r, "/", http.StatusFound)
+		return
 	}
 
-	session, ok := h.service.Session.Find(sessionID)
-	if !ok {
-		log.Printf("Session not found")
-		w.WriteHeader(http.StatusNotFound)
+	session, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
 	if err := h.service.Session.TerminateSession(session); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		h.writeError(w, r, fmt.Errorf("unable to terminate session: %w", err))
 		return
 	}
 
-	_ = h.templates.ExecuteTemplate(w, "terminateGood.html", nil)
+	// сообщение об удалении показывается уже в новой сессии
+	fresh := h.service.Session.New()
+	h.setSessionCookie(fresh.String(), w, r)
+	h.flash(w, r, fresh, "done.terminate")
 }
@@ -0,0 +1,57 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"imgcutter/service"
+
+	"github.com/golang/mock/gomock"
+	"github.com/magiconair/properties/assert"
+)
+
+func TestRouter_TerminateSession(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	ss := service.NewMockSessionService(c)
+	handler := Handler{service: service.Service{Session: ss}}
+
+	old, fresh := &service.Session{}, &service.Session{}
+	ss.EXPECT().Find("old-session-id").Return(old, true)
+	ss.EXPECT().TerminateSession(old).Return(nil)
+	ss.EXPECT().New().Return(fresh)
+	ss.EXPECT().AddFlash(fresh, service.Flash{Key: "done.terminate"}).Return(nil)
+
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/terminate", nil)
+	r = r.WithContext(context.WithValue(context.Background(), ctxSessionKey, "old-session-id"))
+
+	w := httptest.NewRecorder()
+	handler.TerminateSession(w, r)
+
+	// сообщение ждёт на главной странице в новой сессии
+	assert.Equal(t, w.Result().StatusCode, http.StatusSeeOther)
+	assert.Equal(t, w.Result().Header.Get("Location"), "/")
+	assert.Equal(t, w.Result().Cookies()[0].Name, sessionID)
+	assert.Equal(t, w.Result().Cookies()[0].Value, fresh.String())
+}
+
+func TestRouter_TerminateSession_Get(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	// ссылкой сессию больше не завершить, обращений к сервису нет
+	ss := service.NewMockSessionService(c)
+	handler := Handler{service: service.Service{Session: ss}}
+
+	r := httptest.NewRequest(http.MethodGet, "http://example.com/terminate", nil)
+	r = r.WithContext(context.WithValue(context.Background(), ctxSessionKey, "old-session-id"))
+
+	w := httptest.NewRecorder()
+	handler.TerminateSession(w, r)
+
+	assert.Equal(t, w.Result().StatusCode, http.StatusFound)
+	assert.Equal(t, w.Result().Header.Get("Location"), "/")
+}
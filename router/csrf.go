@@ -0,0 +1,52 @@
+package router
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+)
+
+const (
+	csrfField  = "csrf_token"   // hidden field of every form
+	csrfHeader = "X-CSRF-Token" // used by frontend instead of the field
+)
+
+// CheckCSRF rejects state-changing requests without token of their session,
+// so other sites can't act on behalf of the user carrying SESSID cookie.
+func (h *Handler) CheckCSRF(f http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			f(w, r)
+			return
+		}
+
+		s, err := h.session(r)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+		if !validCSRFToken(s.CSRFToken(), requestCSRFToken(r)) {
+			h.writeError(w, r, withStatus(http.StatusForbidden, errors.New(h.t(r, "error.csrf"))))
+			return
+		}
+
+		f(w, r)
+	}
+}
+
+// requestCSRFToken takes token from header or from body of form, token in query is not accepted
+// since it leaks to logs and history.
+func requestCSRFToken(r *http.Request) string {
+	if token := r.Header.Get(csrfHeader); token != "" {
+		return token
+	}
+
+	// форма разбирается здесь, обработчики потом берут поля из уже разобранной
+	return r.PostFormValue(csrfField)
+}
+
+func validCSRFToken(want, got string) bool {
+	return want != "" && subtle.ConstantTimeCompare([]byte(want), []byte(got)) == 1
+}
@@ -0,0 +1,190 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"imgcutter/i18n"
+	"imgcutter/service"
+	"imgcutter/static"
+
+	"github.com/golang/mock/gomock"
+	"github.com/magiconair/properties/assert"
+)
+
+func TestValidCSRFToken(t *testing.T) {
+	testCases := []struct {
+		name      string
+		want, got string
+		valid     bool
+	}{
+		{name: "same", want: "abc", got: "abc", valid: true},
+		{name: "other", want: "abc", got: "abd", valid: false},
+		{name: "prefix", want: "abc", got: "ab", valid: false},
+		{name: "missing", want: "abc", got: "", valid: false},
+		{name: "session without token", want: "", got: "", valid: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, validCSRFToken(tc.want, tc.got), tc.valid)
+		})
+	}
+}
+
+func TestTemplates_CSRF(t *testing.T) {
+	templates, err := parseTemplates(messages, mustSub(static.Templates, "templates"))
+	assert.Equal(t, err, nil)
+
+	for name, data := range testPages() {
+		t.Run(name, func(t *testing.T) {
+			b := bytes.Buffer{}
+			assert.Equal(t, templates.ExecuteTemplate(&b, i18n.Default, name, data), nil)
+
+			// каждая форма несёт токен
+			forms := strings.Count(b.String(), "<form")
+			tokens := strings.Count(b.String(), `name="csrf_token" value="test-token"`)
+			assert.Equal(t, tokens, forms)
+		})
+	}
+}
+
+func TestRouter_CheckCSRF(t *testing.T) {
+	svc := service.NewService(nil)
+	s := svc.Session.New()
+	token := s.CSRFToken()
+
+	multipartBody := func(fields map[string]string) (string, *bytes.Buffer) {
+		var b bytes.Buffer
+		mw := multipart.NewWriter(&b)
+		for k, v := range fields {
+			mw.WriteField(k, v)
+		}
+		mw.Close()
+		return mw.FormDataContentType(), &b
+	}
+
+	testCases := []struct {
+		name         string
+		request      func() *http.Request
+		responseCode int
+	}{
+		{
+			name: "get without token",
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/", nil)
+			},
+			responseCode: http.StatusOK,
+		},
+		{
+			name: "post without token",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/delete", strings.NewReader("fileName=a.png"))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return r
+			},
+			responseCode: http.StatusForbidden,
+		},
+		{
+			name: "post with wrong token",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/delete", strings.NewReader("fileName=a.png&csrf_token=0000"))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return r
+			},
+			responseCode: http.StatusForbidden,
+		},
+		{
+			name: "token in query",
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/terminate?csrf_token="+token, nil)
+			},
+			responseCode: http.StatusForbidden,
+		},
+		{
+			name: "urlencoded form",
+			request: func() *http.Request {
+				form := url.Values{"fileName": {"a.png"}, csrfField: {token}}
+				r := httptest.NewRequest(http.MethodPost, "/delete", strings.NewReader(form.Encode()))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return r
+			},
+			responseCode: http.StatusOK,
+		},
+		{
+			name: "multipart form",
+			request: func() *http.Request {
+				contentType, body := multipartBody(map[string]string{"fileName": "a.png", csrfField: token})
+				r := httptest.NewRequest(http.MethodPost, "/cut", body)
+				r.Header.Set("Content-Type", contentType)
+				return r
+			},
+			responseCode: http.StatusOK,
+		},
+		{
+			name: "header",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodDelete, "/api/files?fileName=a.png", nil)
+				r.Header.Set(csrfHeader, token)
+				return r
+			},
+			responseCode: http.StatusOK,
+		},
+		{
+			name: "wrong header",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodDelete, "/api/files?fileName=a.png", nil)
+				r.Header.Set(csrfHeader, strings.ToUpper(token))
+				return r
+			},
+			responseCode: http.StatusForbidden,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			te := NewMocktemplateExecutor(c)
+			allowErrorPages(te)
+			handler := Handler{templates: te, service: svc}
+
+			r := tc.request()
+			r = r.WithContext(context.WithValue(context.Background(), ctxSessionKey, s.String()))
+
+			w := httptest.NewRecorder()
+			handler.CheckCSRF(func(w http.ResponseWriter, r *http.Request) {
+				// поля формы остаются доступны обработчику
+				if r.Method == http.MethodPost && r.Header.Get("Content-Type") != "" {
+					assert.Equal(t, r.FormValue("fileName"), "a.png")
+				}
+				w.WriteHeader(http.StatusOK)
+			})(w, r)
+			assert.Equal(t, w.Result().StatusCode, tc.responseCode)
+		})
+	}
+}
+
+func TestRouter_APICSRF(t *testing.T) {
+	svc := service.NewService(nil)
+	s := svc.Session.New()
+	handler := Handler{service: svc}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/csrf", nil)
+	r = r.WithContext(context.WithValue(context.Background(), ctxSessionKey, s.String()))
+
+	w := httptest.NewRecorder()
+	handler.APICSRF(w, r)
+	assert.Equal(t, w.Result().StatusCode, http.StatusOK)
+	assert.Equal(t, w.Result().Header.Get("Cache-Control"), "no-store")
+
+	var body apiCSRF
+	assert.Equal(t, json.NewDecoder(w.Body).Decode(&body), nil)
+	assert.Equal(t, body.Token, s.CSRFToken())
+}
@@ -0,0 +1,218 @@
+package router
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"imgcutter/imgprocessing"
+	"imgcutter/service"
+)
+
+var errNoSessionContext = errors.New("no session in request context")
+
+// statusError is error of request with status chosen by handler, like missing form field.
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string { return e.err.Error() }
+func (e *statusError) Unwrap() error { return e.err }
+
+// withStatus makes writeError answer on err with status.
+func withStatus(status int, err error) error {
+	return &statusError{status: status, err: err}
+}
+
+// errorStatuses map typed errors of service and imgprocessing to http statuses and keys
+// of messages shown to client, the first matching one wins. Errors missing here are failures of server.
+var errorStatuses = []struct {
+	err    error
+	status int
+	key    string
+}{
+	{service.ErrFileNotFound, http.StatusNotFound, "error.file_not_found"},
+	{service.ErrPresetNotFound, http.StatusNotFound, "error.preset_not_found"},
+	{service.ErrSessionNotFound, http.StatusNotFound, "error.session_not_found"},
+	{service.ErrNoTiles, http.StatusNotFound, "error.no_tiles"},
+	{fs.ErrNotExist, http.StatusNotFound, "error.file_not_found"},
+
+	{service.ErrPresetReadOnly, http.StatusConflict, "error.preset_read_only"},
+
+	{imgprocessing.ErrImageTooBig, http.StatusRequestEntityTooLarge, "error.image_too_big"},
+	{errRegionsFileTooBig, http.StatusRequestEntityTooLarge, "error.regions_file_too_big"},
+
+	{service.ErrNotImage, http.StatusUnsupportedMediaType, "error.not_image"},
+	{zip.ErrFormat, http.StatusUnsupportedMediaType, "error.not_zip"},
+
+	{service.ErrUnknownMode, http.StatusBadRequest, "error.unknown_mode"},
+	{service.ErrBadPadding, http.StatusBadRequest, "error.bad_padding"},
+	{service.ErrInvalidPreset, http.StatusBadRequest, "error.invalid_preset"},
+	{imgprocessing.ErrSmallCut, http.StatusBadRequest, "error.small_cut"},
+	{imgprocessing.ErrUnsupportedFormat, http.StatusBadRequest, "error.unsupported_format"},
+	{imgprocessing.ErrBadNameTemplate, http.StatusBadRequest, "error.bad_name_template"},
+	{imgprocessing.ErrNameCollision, http.StatusBadRequest, "error.name_collision"},
+	{imgprocessing.ErrBadTileSize, http.StatusBadRequest, "error.bad_tile_size"},
+	{imgprocessing.ErrBadZoom, http.StatusBadRequest, "error.bad_zoom"},
+	{imgprocessing.ErrBadGuides, http.StatusBadRequest, "error.bad_guides"},
+	{imgprocessing.ErrBadRegions, http.StatusBadRequest, "error.bad_regions"},
+	{imgprocessing.ErrRegionOutOfBounds, http.StatusBadRequest, "error.region_out_of_bounds"},
+	{imgprocessing.ErrRegionsOverlap, http.StatusBadRequest, "error.regions_overlap"},
+	{imgprocessing.ErrBadColor, http.StatusBadRequest, "error.bad_color"},
+	{imgprocessing.ErrBadTolerance, http.StatusBadRequest, "error.bad_tolerance"},
+	{imgprocessing.ErrUnknownDetect, http.StatusBadRequest, "error.unknown_detect"},
+	{imgprocessing.ErrNothingToCut, http.StatusBadRequest, "error.nothing_to_cut"},
+	{imgprocessing.ErrUnknownFilter, http.StatusBadRequest, "error.unknown_filter"},
+	{imgprocessing.ErrUnknownResize, http.StatusBadRequest, "error.unknown_resize"},
+	{imgprocessing.ErrBadResize, http.StatusBadRequest, "error.bad_resize"},
+	{imgprocessing.ErrBadCrop, http.StatusBadRequest, "error.bad_crop"},
+	{imgprocessing.ErrBadRotate, http.StatusBadRequest, "error.bad_rotate"},
+	{imgprocessing.ErrBadTransform, http.StatusBadRequest, "error.bad_transform"}, // велик результат, а не запрос
+	{imgprocessing.ErrUnknownFlip, http.StatusBadRequest, "error.unknown_flip"},
+	{imgprocessing.ErrBadScale, http.StatusBadRequest, "error.bad_scale"},
+	{imgprocessing.ErrBadArchive, http.StatusBadRequest, "error.bad_archive"},
+	{imgprocessing.ErrBadGrid, http.StatusBadRequest, "error.bad_grid"},
+	{imgprocessing.ErrEmptyGrid, http.StatusBadRequest, "error.empty_grid"},
+}
+
+// errorStatus chooses http status for err.
+func errorStatus(err error) int {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.status
+	}
+
+	for _, e := range errorStatuses {
+		if errors.Is(err, e.err) {
+			return e.status
+		}
+	}
+
+	return http.StatusInternalServerError
+}
+
+// errorPage is data of "error.html" template.
+type errorPage struct {
+	Status int
+	Title  string // what status means
+	Cause  string
+	Home   string // link to main page, error may happen under any path
+}
+
+// writeError answers on err with its status: JSON for api and clients preferring it,
+// error page for browsers. Details of failures of server stay in log.
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status := errorStatus(err)
+	log.Printf("answering %d: %v", status, err)
+
+	cause := h.errorCause(r, err, status)
+
+	if wantsJSON(r) {
+		writeJSON(w, status, apiError{Error: cause})
+		return
+	}
+
+	title, err := messages.Translate(h.locale(r), "error.status_"+strconv.Itoa(status))
+	if err != nil {
+		title = http.StatusText(status)
+	}
+
+	page := errorPage{Status: status, Title: title, Cause: cause, Home: h.prefix(r) + "/"}
+	b := bytes.Buffer{}
+
+	if h.templates != nil {
+		if err := h.templates.ExecuteTemplate(&b, h.locale(r), "error.html", page); err != nil {
+			log.Printf("error rendering error page: %v", err)
+			b.Reset()
+		}
+	}
+
+	if b.Len() == 0 {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		fmt.Fprint(w, cause)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(b.Bytes())
+}
+
+// errorCause describes err for client in its locale. Typed errors are translated with keys
+// of errorStatuses, messages of other errors of request are translated by handlers.
+func (h *Handler) errorCause(r *http.Request, err error, status int) string {
+	if status >= http.StatusInternalServerError {
+		return h.t(r, "error.internal")
+	}
+
+	for _, e := range errorStatuses {
+		if errors.Is(err, e.err) {
+			return h.t(r, e.key)
+		}
+	}
+
+	return err.Error()
+}
+
+// wantsJSON tells if error of request should be JSON: api is always answered with it,
+// other clients get it if Accept ranks "application/json" above "text/html".
+func wantsJSON(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		return true
+	}
+
+	q := map[string]float64{}
+
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		weight := 1.0
+		if v, ok := params["q"]; ok {
+			if weight, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		q[mediaType] = weight
+	}
+
+	return q["application/json"] > q["text/html"]
+}
+
+// session finds session of request put into context by ManageSession.
+func (h *Handler) session(r *http.Request) (*service.Session, error) {
+	sessionID, ok := r.Context().Value(ctxSessionKey).(string)
+	if !ok {
+		return nil, errNoSessionContext
+	}
+
+	s, ok := h.service.Session.Find(sessionID)
+	if !ok {
+		return nil, service.ErrSessionNotFound
+	}
+
+	return s, nil
+}
+
+// badRequest wraps err of parsing request with what was parsed.
+func badRequest(what string, err error) error {
+	return withStatus(http.StatusBadRequest, fmt.Errorf("%s: %w", what, err))
+}
+
+// missingField is error of request lacking form or query field name.
+func (h *Handler) missingField(r *http.Request, name string) error {
+	return withStatus(http.StatusBadRequest, errors.New(h.t(r, "error.missing_field", name)))
+}
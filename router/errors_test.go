@@ -0,0 +1,172 @@
+package router
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"imgcutter/imgprocessing"
+	"imgcutter/service"
+	"imgcutter/static"
+
+	"github.com/golang/mock/gomock"
+	"github.com/magiconair/properties/assert"
+)
+
+// allowErrorPages lets handler render "error.html" with mocked templates, the page is left empty.
+func allowErrorPages(te *MocktemplateExecutor) {
+	te.EXPECT().ExecuteTemplate(gomock.Any(), gomock.Any(), "error.html", gomock.Any()).Return(nil).AnyTimes()
+}
+
+func TestErrorStatus(t *testing.T) {
+	testCases := []struct {
+		err    error
+		status int
+	}{
+		{err: service.ErrFileNotFound, status: http.StatusNotFound},
+		{err: fmt.Errorf("error getting archive name: %w", service.ErrFileNotFound), status: http.StatusNotFound},
+		{err: service.ErrSessionNotFound, status: http.StatusNotFound},
+		{err: service.ErrNoTiles, status: http.StatusNotFound},
+		{err: service.ErrPresetReadOnly, status: http.StatusConflict},
+		{err: fmt.Errorf("error on cut img: %w", imgprocessing.ErrSmallCut), status: http.StatusBadRequest},
+		{err: fmt.Errorf("%w: %v", imgprocessing.ErrBadArchive, zip.ErrFormat), status: http.StatusBadRequest},
+		{err: imgprocessing.ErrImageTooBig, status: http.StatusRequestEntityTooLarge},
+		{err: fmt.Errorf("error on transform img: %w: 40000x40000 px", imgprocessing.ErrBadTransform), status: http.StatusBadRequest},
+		{err: fmt.Errorf("%w: %v", service.ErrNotImage, "bad jpeg"), status: http.StatusUnsupportedMediaType},
+		{err: withStatus(http.StatusMethodNotAllowed, errors.New("use POST")), status: http.StatusMethodNotAllowed},
+		{err: badRequest("error parsing form", service.ErrFS), status: http.StatusBadRequest},
+		{err: service.ErrFS, status: http.StatusInternalServerError},
+		{err: errNoSessionContext, status: http.StatusInternalServerError},
+		{err: errors.New("something unexpected"), status: http.StatusInternalServerError},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, errorStatus(tc.err), tc.status, tc.err.Error())
+	}
+}
+
+// Every typed error is explained to client in its language.
+func TestErrorStatuses_Messages(t *testing.T) {
+	for _, e := range errorStatuses {
+		_, err := messages.Translate("en", e.key)
+		assert.Equal(t, err, nil, e.err.Error())
+	}
+}
+
+func TestWantsJSON(t *testing.T) {
+	testCases := []struct {
+		path   string
+		accept string
+		want   bool
+	}{
+		{path: "/cut", accept: "", want: false},
+		{path: "/cut", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", want: false},
+		{path: "/cut", accept: "application/json", want: true},
+		{path: "/cut", accept: "text/html;q=0.5, application/json", want: true},
+		{path: "/cut", accept: "application/json;q=0.5, text/html", want: false},
+		{path: "/api/cut", accept: "text/html", want: true},
+	}
+	for _, tc := range testCases {
+		r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		r.Header.Set("Accept", tc.accept)
+		assert.Equal(t, wantsJSON(r), tc.want, tc.path+" "+tc.accept)
+	}
+}
+
+func TestRouter_WriteError(t *testing.T) {
+	templates, err := parseTemplates(messages, mustSub(static.Templates, "templates"))
+	assert.Equal(t, err, nil)
+
+	testCases := []struct {
+		name        string
+		err         error
+		accept      string
+		locale      string
+		status      int
+		contentType string
+		body        string
+	}{
+		{
+			name:        "page",
+			err:         fmt.Errorf("error on cut img: %w", imgprocessing.ErrSmallCut),
+			status:      http.StatusBadRequest,
+			contentType: "text/html; charset=utf-8",
+			body:        "cut too small",
+		},
+		{
+			name:        "translated cause",
+			err:         fmt.Errorf("error on cut img: %w", imgprocessing.ErrSmallCut),
+			locale:      "ru",
+			status:      http.StatusBadRequest,
+			contentType: "text/html; charset=utf-8",
+			body:        "слишком маленький кусок",
+		},
+		{
+			name:        "translated json",
+			err:         badRequest("error parsing cut params", service.ErrUnknownMode),
+			accept:      "application/json",
+			locale:      "ru",
+			status:      http.StatusBadRequest,
+			contentType: "application/json",
+			body:        `{"error":"неизвестный режим нарезки"}`,
+		},
+		{
+			name:        "localized page",
+			err:         service.ErrFileNotFound,
+			locale:      "ru",
+			status:      http.StatusNotFound,
+			contentType: "text/html; charset=utf-8",
+			body:        "Ошибка 404: Не найдено",
+		},
+		{
+			name:        "json",
+			err:         service.ErrPresetReadOnly,
+			accept:      "application/json",
+			status:      http.StatusConflict,
+			contentType: "application/json",
+			body:        `{"error":"built-in preset can not be changed"}`,
+		},
+		{
+			name:        "failure of server",
+			err:         fmt.Errorf("unable to remove temp/secret/a.zip: %w", service.ErrFS),
+			accept:      "application/json",
+			status:      http.StatusInternalServerError,
+			contentType: "application/json",
+			body:        `{"error":"Internal Server Error"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := Handler{templates: templates}
+
+			r := httptest.NewRequest(http.MethodPost, "/cut", nil)
+			r.Header.Set("Accept", tc.accept)
+			if tc.locale != "" {
+				r = r.WithContext(context.WithValue(r.Context(), ctxLocaleKey, tc.locale))
+			}
+
+			w := httptest.NewRecorder()
+			handler.writeError(w, r, tc.err)
+
+			assert.Equal(t, w.Result().StatusCode, tc.status)
+			assert.Equal(t, w.Result().Header.Get("Content-Type"), tc.contentType)
+			assert.Equal(t, strings.Contains(w.Body.String(), tc.body), true, w.Body.String())
+
+			if tc.contentType == "application/json" {
+				var body apiError
+				assert.Equal(t, json.Unmarshal(w.Body.Bytes(), &body), nil)
+			}
+		})
+	}
+
+	// без шаблонов ответ остаётся текстом
+	w := httptest.NewRecorder()
+	(&Handler{}).writeError(w, httptest.NewRequest(http.MethodGet, "/gallery", nil), service.ErrFileNotFound)
+	assert.Equal(t, w.Result().StatusCode, http.StatusNotFound)
+	assert.Equal(t, w.Body.String(), "file not found")
+}
@@ -1,7 +0,0 @@
-package router
-
-import "net/http"
-
-func (h *Handler) favicon(w http.ResponseWriter, r *http.Request) {
-	http.ServeFile(w, r, "static/favicon.ico")
-}
@@ -2,128 +2,244 @@ package router
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"path/filepath"
 	"strconv"
+	"strings"
+
+	"imgcutter/imgprocessing"
+	"imgcutter/service"
 )
 
-func (h *Handler) CutFile(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseForm(); err != nil {
-		log.Printf("err parsing form: %v", err)
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprint(w, "Bad Request")
+// homePage is data of "home.html" template.
+type homePage struct {
+	Files     []service.MyFile
+	Presets   []service.Preset
+	Flashes   []string // outcomes of previous actions
+	CSRFToken string   // goes into every form
+}
 
-		return
+// parseCutParams reads cut settings of form: "dX", "dY", "mode", "format", "naming", "manifestCSV",
+// "dedup", "padding", "detect", "background", "tolerance", "tileSize", "overlap",
+// "skipBlank", "minZoom", "maxZoom", "tms", "resize", "resizeWidth", "resizeHeight", "filter",
+// "crop", "rotate", "flip", "scale", comma separated "guidesX", "guidesY", "columns", "rows"
+// and json "regions". Empty fields get default values.
+func parseCutParams(form url.Values) (service.CutParams, error) {
+	var (
+		params service.CutParams
+		err    error
+	)
+
+	for key, dest := range map[string]*int{
+		"dX":           &params.DX,
+		"dY":           &params.DY,
+		"padding":      &params.Padding,
+		"tolerance":    &params.Tolerance,
+		"tileSize":     &params.TileSize,
+		"overlap":      &params.Overlap,
+		"minZoom":      &params.MinZoom,
+		"maxZoom":      &params.MaxZoom,
+		"resizeWidth":  &params.ResizeWidth,
+		"resizeHeight": &params.ResizeHeight,
+		"rotate":       &params.Rotate,
+	} {
+		if form.Get(key) == "" {
+			continue
+		}
+
+		if *dest, err = strconv.Atoi(form.Get(key)); err != nil {
+			return service.CutParams{}, fmt.Errorf("error parsing %s: %w", key, err)
+		}
+	}
+
+	if scale := form.Get("scale"); scale != "" {
+		if params.Scale, err = strconv.ParseFloat(scale, 64); err != nil {
+			return service.CutParams{}, fmt.Errorf("error parsing scale: %w", err)
+		}
+	}
+
+	for key, dest := range map[string]*[]int{
+		"guidesX": &params.GuidesX,
+		"guidesY": &params.GuidesY,
+		"columns": &params.Columns,
+		"rows":    &params.Rows,
+	} {
+		if *dest, err = parseIntList(form.Get(key)); err != nil {
+			return service.CutParams{}, fmt.Errorf("error parsing %s: %w", key, err)
+		}
+	}
+
+	if regions := form.Get("regions"); strings.TrimSpace(regions) != "" {
+		if params.Regions, err = imgprocessing.ParseRegions([]byte(regions)); err != nil {
+			return service.CutParams{}, err
+		}
+	}
+
+	if params.Mode, err = service.ParseCutMode(form.Get("mode")); err != nil {
+		return service.CutParams{}, err
+	}
+
+	if params.Format, err = imgprocessing.ParseFormat(form.Get("format")); err != nil {
+		return service.CutParams{}, err
+	}
+
+	params.Naming = form.Get("naming")
+	params.ManifestCSV = form.Get("manifestCSV") != ""
+	params.Dedup = form.Get("dedup") != ""
+	params.TMS = form.Get("tms") != ""
+	params.SkipBlank = form.Get("skipBlank") != ""
+	params.Detect = form.Get("detect")
+	params.Background = form.Get("background")
+	params.Resize = form.Get("resize")
+	params.Filter = form.Get("filter")
+	params.Crop = form.Get("crop")
+	params.Flip = form.Get("flip")
+
+	if err := params.Validate(); err != nil {
+		return service.CutParams{}, err
+	}
+
+	return params, nil
+}
+
+// readRegionsFile puts content of uploaded "regionsFile" into "regions" form field if the last is empty.
+func readRegionsFile(r *http.Request) error {
+	if r.MultipartForm == nil || len(r.MultipartForm.File["regionsFile"]) == 0 || r.PostForm.Get("regions") != "" {
+		return nil
 	}
 
-	if !r.PostForm.Has("fileName") {
-		log.Printf(`request form missing field "fileName"`)
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprint(w, "Bad Request")
+	header := r.MultipartForm.File["regionsFile"][0]
+	if header.Size > maxRegionsSpecSize {
+		return fmt.Errorf("%w: %d bytes", errRegionsFileTooBig, header.Size)
+	}
 
-		return
+	f, err := header.Open()
+	if err != nil {
+		return err
 	}
-	fileName := r.PostForm.Get("fileName")
+	defer f.Close()
 
-	dX, err := strconv.Atoi(r.PostForm.Get("dX"))
+	spec, err := io.ReadAll(io.LimitReader(f, maxRegionsSpecSize))
 	if err != nil {
-		log.Printf("error parsing dX: %v", err)
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprint(w, "Bad Request")
+		return err
+	}
 
-		return
+	r.PostForm.Set("regions", string(spec))
+
+	return nil
+}
+
+// parseIntList reads "120, 600, 120", empty string gives nil.
+func parseIntList(s string) ([]int, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
 	}
 
-	dY, err := strconv.Atoi(r.PostForm.Get("dY"))
-	if err != nil {
-		log.Printf("error parsing dY: %v", err)
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprint(w, "Bad Request")
+	parts := strings.Split(s, ",")
+	out := make([]int, len(parts))
 
-		return
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return nil, err
+		}
+		out[i] = n
 	}
 
-	log.Printf("cutting file: %v, dX: %v px, dY: %v px", filepath.Base(fileName), dX, dY)
+	return out, nil
+}
+
+// maxRegionsSpecSize limits uploaded "regionsFile" of /cut.
+const maxRegionsSpecSize = 1 << 20
 
-	sessionID, ok := r.Context().Value(ctxSessionKey).(string)
-	if !ok {
-		log.Printf("unable to get context value")
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Internal Server Error")
+var errRegionsFileTooBig = errors.New("regions file is too big")
 
+func (h *Handler) CutFile(w http.ResponseWriter, r *http.Request) {
+	// форма может быть multipart, если приложен файл с регионами
+	if err := r.ParseMultipartForm(maxRegionsSpecSize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		h.writeError(w, r, badRequest("error parsing form", err))
 		return
 	}
 
-	session, ok := h.service.Session.Find(sessionID)
-	if !ok {
-		log.Printf("session not found")
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprint(w, "Bad Session")
-
+	if err := readRegionsFile(r); err != nil {
+		h.writeError(w, r, badRequest("error reading regions file", err))
 		return
 	}
 
-	if err := h.service.Files.CutFile(session, fileName, dX, dY); err != nil {
-		log.Printf("error processing img: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Internal Server Error")
-
+	if !r.PostForm.Has("fileName") {
+		h.writeError(w, r, h.missingField(r, "fileName"))
 		return
 	}
+	fileName := r.PostForm.Get("fileName")
+	presetName := r.PostForm.Get("preset")
 
-	log.Printf("file %s succsesfully cut", filepath.Base(fileName))
+	var params service.CutParams
 
-	b := bytes.Buffer{}
-
-	if err := h.templates.ExecuteTemplate(&b, "cutGood.html", fileName); err != nil {
-		log.Println(err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Internal Server Error")
+	if presetName == "" {
+		p, err := parseCutParams(r.PostForm)
+		if err != nil {
+			h.writeError(w, r, badRequest("error parsing cut params", err))
+			return
+		}
+		params = p
+	}
 
+	session, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write(b.Bytes())
-}
+	if presetName != "" {
+		preset, err := h.service.Presets.FindPreset(session, presetName)
+		if err != nil {
+			h.writeError(w, r, fmt.Errorf("error finding preset %q: %w", presetName, err))
+			return
+		}
+		params = preset.CutParams
+	}
 
-func (h *Handler) MainPage(w http.ResponseWriter, r *http.Request) {
-	sessionID, ok := r.Context().Value(ctxSessionKey).(string)
-	if !ok {
-		log.Printf("unable to get context value")
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Internal Server Error")
+	log.Printf("cutting file: %v, dX: %v px, dY: %v px, mode: %s, format: %s",
+		filepath.Base(fileName), params.DX, params.DY, params.Mode, params.Format)
 
+	if err := h.service.Files.CutFile(session, fileName, params); err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
-	s, ok := h.service.Session.Find(sessionID)
-	if !ok {
-		log.Printf("session not found")
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprint(w, "Bad Session")
+	log.Printf("file %s succsesfully cut", filepath.Base(fileName))
+	h.flash(w, r, session, "done.cut", filepath.Base(fileName))
+}
 
+func (h *Handler) MainPage(w http.ResponseWriter, r *http.Request) {
+	s, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
 	filesList, err := h.service.Files.GetFiles(s)
 	if err != nil {
-		log.Printf("unable to get files list")
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Internal Server Error")
+		h.writeError(w, r, fmt.Errorf("unable to get files list: %w", err))
+		return
+	}
 
+	presets, err := h.service.Presets.GetPresets(s)
+	if err != nil {
+		h.writeError(w, r, fmt.Errorf("unable to get presets list: %w", err))
 		return
 	}
 
 	b := bytes.Buffer{}
 
-	if err := h.templates.ExecuteTemplate(&b, "home.html", filesList); err != nil {
-		log.Println(err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Internal Server Error")
-
+	page := homePage{Files: filesList, Presets: presets, Flashes: h.flashMessages(r, s), CSRFToken: s.CSRFToken()}
+	if err := h.templates.ExecuteTemplate(&b, h.locale(r), "home.html", page); err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
@@ -133,51 +249,31 @@ func (h *Handler) MainPage(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) DownloadFile(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Redirect(w, r, "/", http.StatusFound)
+		h.redirect(w, r, "/", http.StatusFound)
 		return
 	}
 
 	if err := r.ParseForm(); err != nil {
-		log.Printf("err parsing form: %v", err)
-		w.WriteHeader(http.StatusBadRequest)
-
+		h.writeError(w, r, badRequest("error parsing form", err))
 		return
 	}
 
 	if !r.PostForm.Has("fileName") {
-		log.Printf(`request form missing field "fileName"`)
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprint(w, "Bad Request")
-
+		h.writeError(w, r, h.missingField(r, "fileName"))
 		return
 	}
 	fileName := r.PostForm.Get("fileName")
 	log.Printf("downloading archive of: %v", fileName)
 
-	sessionID, ok := r.Context().Value(ctxSessionKey).(string)
-	if !ok {
-		log.Printf("unable to get context value")
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Internal Server Error")
-
-		return
-	}
-
-	s, ok := h.service.Session.Find(sessionID)
-	if !ok {
-		log.Printf("session not found")
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprint(w, "Bad Session")
-
+	s, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
 	archiveName, err := h.service.Files.GetArchiveName(s, fileName)
 	if err != nil {
-		log.Printf("error getting archive name: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Internal Server Error")
-
+		h.writeError(w, r, fmt.Errorf("error getting archive name: %w", err))
 		return
 	}
 
@@ -188,34 +284,19 @@ func (h *Handler) DownloadFile(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) UploadFile(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Redirect(w, r, "/", http.StatusFound)
-		return
-	}
-
-	sessionID, ok := r.Context().Value(ctxSessionKey).(string)
-	if !ok {
-		log.Printf("unable to get context value")
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Internal Server Error")
-
+		h.redirect(w, r, "/", http.StatusFound)
 		return
 	}
 
-	s, ok := h.service.Session.Find(sessionID)
-	if !ok {
-		log.Printf("session not found")
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprint(w, "Bad Session")
-
+	s, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
 	uploadingFile, fileHeader, err := r.FormFile("uploadingFile")
 	if err != nil {
-		log.Printf("FormFile error: %v", err)
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprint(w, "Bad Request")
-
+		h.writeError(w, r, badRequest("error reading uploaded file", err))
 		return
 	}
 	defer uploadingFile.Close()
@@ -225,94 +306,261 @@ func (h *Handler) UploadFile(w http.ResponseWriter, r *http.Request) {
 
 	if !(contentType == "image/jpeg" || contentType == "image/png") {
 		log.Printf("invalid fileHeader content-type: %s", contentType)
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprint(w, "file must be .jpg (or .png)")
+		h.writeError(w, r, withStatus(http.StatusUnsupportedMediaType, errors.New(h.t(r, "error.not_image"))))
 
 		return
 	}
 
 	if err := h.service.Files.UploadFile(s, uploadingFile, fileName); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Internal Server Error")
+		h.writeError(w, r, err)
+		return
+	}
+
+	log.Printf("file %s succsesfully uploaded", fileName)
+	h.flash(w, r, s, "done.upload", fileName)
+}
 
+func (h *Handler) DeleteFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.redirect(w, r, "/", http.StatusFound)
 		return
 	}
 
-	b := bytes.Buffer{}
+	session, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
 
-	if err := h.templates.ExecuteTemplate(&b, "uploadGood.html", fileName); err != nil {
-		log.Println(err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Internal Server Error")
+	if err := r.ParseForm(); err != nil {
+		h.writeError(w, r, badRequest("error parsing form", err))
+		return
+	}
 
+	if !r.PostForm.Has("fileName") {
+		h.writeError(w, r, h.missingField(r, "fileName"))
 		return
 	}
+	fileName := r.PostForm.Get("fileName")
 
-	log.Printf("file %s succsesfully uploaded", fileName)
-	w.WriteHeader(http.StatusOK)
-	w.Write(b.Bytes())
+	if err := h.service.Files.DeleteFile(session, fileName); err != nil {
+		h.writeError(w, r, fmt.Errorf("unable to delete file: %w", err))
+		return
+	}
+
+	log.Printf("file %s succsesfully deleted", fileName)
+	h.flash(w, r, session, "done.delete", filepath.Base(fileName))
 }
 
-func (h *Handler) DeleteFile(w http.ResponseWriter, r *http.Request) {
+// zipMagic starts local file header, the first record of any non-empty zip archive.
+var zipMagic = []byte("PK\x03\x04")
+
+func isZip(f io.ReaderAt) bool {
+	magic := make([]byte, len(zipMagic))
+	if _, err := f.ReadAt(magic, 0); err != nil {
+		return false
+	}
+
+	return bytes.Equal(magic, zipMagic)
+}
+
+func (h *Handler) StitchFile(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Redirect(w, r, "/", http.StatusFound)
+		h.redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	s, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	archive, fileHeader, err := r.FormFile("archive")
+	if err != nil {
+		h.writeError(w, r, badRequest("error reading uploaded archive", err))
+		return
+	}
+	defer archive.Close()
+
+	// браузеры и curl присылают zip кто как (application/x-zip-compressed, application/octet-stream),
+	// поэтому смотрим на сигнатуру, а не на content-type
+	if !isZip(archive) {
+		log.Printf("not a zip archive, content-type: %s", fileHeader.Header.Get("content-type"))
+		h.writeError(w, r, withStatus(http.StatusUnsupportedMediaType, errors.New(h.t(r, "error.not_zip"))))
+
 		return
 	}
 
-	sessionID, ok := r.Context().Value(ctxSessionKey).(string)
-	if !ok {
-		log.Printf("unable to get context value")
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Internal Server Error")
+	format, err := imgprocessing.ParseFormat(r.FormValue("format"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
 
+	fileName, err := h.service.Files.StitchFile(s, archive, fileHeader.Size, fileHeader.Filename, format)
+	if err != nil {
+		h.writeError(w, r, fmt.Errorf("error stitching archive: %w", err))
 		return
 	}
 
-	session, ok := h.service.Session.Find(sessionID)
-	if !ok {
-		log.Printf("session not found")
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprint(w, "Bad Session")
+	log.Printf("archive %s succsesfully stitched", fileHeader.Filename)
 
+	w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(filepath.Base(fileName)))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeFile(w, r, fileName)
+}
+
+func (h *Handler) SpriteFiles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.redirect(w, r, "/", http.StatusFound)
 		return
 	}
 
 	if err := r.ParseForm(); err != nil {
-		log.Printf("err parsing form: %v", err)
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprint(w, "Bad Request")
+		h.writeError(w, r, badRequest("error parsing form", err))
+		return
+	}
 
+	fileNames := r.PostForm["fileName"]
+	if len(fileNames) == 0 {
+		h.writeError(w, r, h.missingField(r, "fileName"))
 		return
 	}
 
-	if !r.PostForm.Has("fileName") {
-		log.Printf(`request form missing field "fileName"`)
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprint(w, "Bad Request")
+	params := service.CutParams{Mode: service.ModeSprite, Format: imgprocessing.FormatPNG}
+
+	if r.PostForm.Get("format") != "" {
+		format, err := imgprocessing.ParseFormat(r.PostForm.Get("format"))
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		params.Format = format
+	}
 
+	if r.PostForm.Get("padding") != "" {
+		padding, err := strconv.Atoi(r.PostForm.Get("padding"))
+		if err != nil {
+			h.writeError(w, r, badRequest("error parsing padding", err))
+			return
+		}
+
+		if padding < 0 {
+			h.writeError(w, r, service.ErrBadPadding)
+			return
+		}
+		params.Padding = padding
+	}
+
+	s, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
 		return
 	}
-	fileName := r.PostForm.Get("fileName")
 
-	if err := h.service.Files.DeleteFile(session, fileName); err != nil {
-		log.Printf("unable to delete files")
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Internal Server Error")
+	archiveName, err := h.service.Files.SpriteFiles(s, fileNames, params)
+	if err != nil {
+		h.writeError(w, r, fmt.Errorf("error packing sprites: %w", err))
+		return
+	}
+
+	// на диске у листа уникальный суффикс, пользователю он ни к чему
+	w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote("sprites.zip"))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeFile(w, r, archiveName)
+}
 
+// Thumbnail serves thumbnail of uploaded file "fileName" of query.
+func (h *Handler) Thumbnail(w http.ResponseWriter, r *http.Request) {
+	fileName := r.URL.Query().Get("fileName")
+	if fileName == "" {
+		h.writeError(w, r, h.missingField(r, "fileName"))
 		return
 	}
 
-	b := bytes.Buffer{}
+	s, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	thumbnail, err := h.service.Files.GetThumbnail(s, fileName)
+	if err != nil {
+		h.writeError(w, r, fmt.Errorf("error getting thumbnail: %w", err))
+		return
+	}
+
+	// ссылка на миниатюру содержит время загрузки, так что её можно кэшировать
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	w.Write(thumbnail)
+}
 
-	if err := h.templates.ExecuteTemplate(&b, "deleteGood.html", fileName); err != nil {
-		log.Println(err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Internal Server Error")
+// PreviewFile renders png of file with outlines of tiles, query has the same fields as /cut form
+// and optional "size" of the longer side of preview.
+func (h *Handler) PreviewFile(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	fileName := query.Get("fileName")
 
+	if fileName == "" {
+		h.writeError(w, r, h.missingField(r, "fileName"))
 		return
 	}
 
-	log.Printf("file %s succsesfully deleted", fileName)
-	w.WriteHeader(http.StatusOK)
-	w.Write(b.Bytes())
+	maxSize := imgprocessing.DefaultPreviewSize
+	if query.Get("size") != "" {
+		size, err := strconv.Atoi(query.Get("size"))
+		if err == nil && (size < 1 || size > imgprocessing.MaxTileSize) {
+			err = fmt.Errorf("size must be in range 1..%d", imgprocessing.MaxTileSize)
+		}
+
+		if err != nil {
+			h.writeError(w, r, badRequest("error parsing preview size", err))
+			return
+		}
+		maxSize = size
+	}
+
+	var params service.CutParams
+
+	if query.Get("preset") == "" {
+		p, err := parseCutParams(query)
+		if err != nil {
+			h.writeError(w, r, badRequest("error parsing cut params", err))
+			return
+		}
+		params = p
+	}
+
+	s, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	if presetName := query.Get("preset"); presetName != "" {
+		preset, err := h.service.Presets.FindPreset(s, presetName)
+		if err != nil {
+			h.writeError(w, r, fmt.Errorf("error finding preset %q: %w", presetName, err))
+			return
+		}
+		params = preset.CutParams
+	}
+
+	img, err := h.service.Files.PreviewFile(s, fileName, params, maxSize)
+	if err != nil {
+		h.writeError(w, r, fmt.Errorf("error rendering preview: %w", err))
+		return
+	}
+
+	buf := bytes.Buffer{}
+	if err := imgprocessing.Encode(&buf, img, imgprocessing.FormatPNG); err != nil {
+		h.writeError(w, r, fmt.Errorf("error encoding preview: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(buf.Bytes())
 }
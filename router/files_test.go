@@ -6,6 +6,9 @@ import (
 	"crypto/md5"
 	"errors"
 	"fmt"
+	"image"
+	"imgcutter/i18n"
+	"imgcutter/imgprocessing"
 	"imgcutter/service"
 	"io"
 	"mime/multipart"
@@ -49,14 +52,14 @@ func TestRouter_CutFile(t *testing.T) {
 			},
 			sessionServiceBehaviour: func(ss *service.MockSessionService, sessionID string) {
 				ss.EXPECT().Find(sessionID).Return(&service.Session{}, true)
+				ss.EXPECT().AddFlash(&service.Session{}, service.Flash{Key: "done.cut", Args: []string{"filename"}}).Return(nil)
 			},
 			fileServiceBehaviour: func(fs *service.MockFileService, session *service.Session, cutParams cutParams) {
-				fs.EXPECT().CutFile(session, cutParams.filename, cutParams.dX, cutParams.dY).Return(nil)
+				fs.EXPECT().CutFile(session, cutParams.filename, service.CutParams{DX: cutParams.dX, DY: cutParams.dY, Mode: service.ModeGrid, Format: imgprocessing.FormatJPEG}).Return(nil)
 			},
 			templateBehavior: func(te *MocktemplateExecutor, fileName string) {
-				te.EXPECT().ExecuteTemplate(&bytes.Buffer{}, "cutGood.html", fileName).Return(nil)
 			},
-			responseCode: http.StatusOK,
+			responseCode: http.StatusSeeOther,
 		},
 		{
 			name:        "missing field fileName",
@@ -90,6 +93,84 @@ func TestRouter_CutFile(t *testing.T) {
 			},
 			responseCode: http.StatusBadRequest,
 		},
+		{
+			name:        "bad name template",
+			sessionID:   "random-uuid",
+			formContent: map[string]string{"fileName": "filename", "dX": "250", "dY": "250", "naming": "tile_{row}.png"},
+			cutParams:   cutParams{},
+			ctxRequest: func(r *http.Request, sessionID string) *http.Request {
+				return r.WithContext(context.WithValue(context.Background(), ctxSessionKey, sessionID))
+			},
+			sessionServiceBehaviour: func(ss *service.MockSessionService, sessionID string) {
+			},
+			fileServiceBehaviour: func(fs *service.MockFileService, session *service.Session, cutParams cutParams) {
+			},
+			templateBehavior: func(te *MocktemplateExecutor, fileName string) {
+			},
+			responseCode: http.StatusBadRequest,
+		},
+		{
+			name:        "guides",
+			sessionID:   "random-uuid",
+			formContent: map[string]string{"fileName": "filename", "mode": "guides", "columns": "120, 600,120", "guidesY": "50"},
+			cutParams:   cutParams{filename: "filename"},
+			ctxRequest: func(r *http.Request, sessionID string) *http.Request {
+				return r.WithContext(context.WithValue(context.Background(), ctxSessionKey, sessionID))
+			},
+			sessionServiceBehaviour: func(ss *service.MockSessionService, sessionID string) {
+				ss.EXPECT().Find(sessionID).Return(&service.Session{}, true)
+				ss.EXPECT().AddFlash(&service.Session{}, service.Flash{Key: "done.cut", Args: []string{"filename"}}).Return(nil)
+			},
+			fileServiceBehaviour: func(fs *service.MockFileService, session *service.Session, cutParams cutParams) {
+				fs.EXPECT().CutFile(session, cutParams.filename, service.CutParams{
+					Mode: service.ModeGuides, Format: imgprocessing.FormatJPEG, Columns: []int{120, 600, 120}, GuidesY: []int{50},
+				}).Return(nil)
+			},
+			templateBehavior: func(te *MocktemplateExecutor, fileName string) {
+			},
+			responseCode: http.StatusSeeOther,
+		},
+		{
+			name:      "regions",
+			sessionID: "random-uuid",
+			formContent: map[string]string{
+				"fileName": "filename", "mode": "regions",
+				"regions": `[{"name": "logo", "x": 0, "y": 0, "width": 120, "height": 40}]`,
+			},
+			cutParams: cutParams{filename: "filename"},
+			ctxRequest: func(r *http.Request, sessionID string) *http.Request {
+				return r.WithContext(context.WithValue(context.Background(), ctxSessionKey, sessionID))
+			},
+			sessionServiceBehaviour: func(ss *service.MockSessionService, sessionID string) {
+				ss.EXPECT().Find(sessionID).Return(&service.Session{}, true)
+				ss.EXPECT().AddFlash(&service.Session{}, service.Flash{Key: "done.cut", Args: []string{"filename"}}).Return(nil)
+			},
+			fileServiceBehaviour: func(fs *service.MockFileService, session *service.Session, cutParams cutParams) {
+				fs.EXPECT().CutFile(session, cutParams.filename, service.CutParams{
+					Mode: service.ModeRegions, Format: imgprocessing.FormatJPEG,
+					Regions: []imgprocessing.Region{{Name: "logo", Rect: imgprocessing.Rect{Width: 120, Height: 40}}},
+				}).Return(nil)
+			},
+			templateBehavior: func(te *MocktemplateExecutor, fileName string) {
+			},
+			responseCode: http.StatusSeeOther,
+		},
+		{
+			name:        "bad guides",
+			sessionID:   "random-uuid",
+			formContent: map[string]string{"fileName": "filename", "mode": "guides", "guidesX": "120, x"},
+			cutParams:   cutParams{},
+			ctxRequest: func(r *http.Request, sessionID string) *http.Request {
+				return r.WithContext(context.WithValue(context.Background(), ctxSessionKey, sessionID))
+			},
+			sessionServiceBehaviour: func(ss *service.MockSessionService, sessionID string) {
+			},
+			fileServiceBehaviour: func(fs *service.MockFileService, session *service.Session, cutParams cutParams) {
+			},
+			templateBehavior: func(te *MocktemplateExecutor, fileName string) {
+			},
+			responseCode: http.StatusBadRequest,
+		},
 		{
 			name:        "no ctx value",
 			sessionID:   "",
@@ -124,7 +205,7 @@ func TestRouter_CutFile(t *testing.T) {
 			responseCode: http.StatusNotFound,
 		},
 		{
-			name:        "template error",
+			name:        "cut too small",
 			sessionID:   "random-uuid",
 			formContent: map[string]string{"fileName": "filename", "dX": "250", "dY": "250"},
 			cutParams:   cutParams{"filename", 250, 250},
@@ -135,12 +216,12 @@ func TestRouter_CutFile(t *testing.T) {
 				ss.EXPECT().Find(sessionID).Return(&service.Session{}, true)
 			},
 			fileServiceBehaviour: func(fs *service.MockFileService, session *service.Session, cutParams cutParams) {
-				fs.EXPECT().CutFile(session, cutParams.filename, cutParams.dX, cutParams.dY).Return(nil)
+				fs.EXPECT().CutFile(session, cutParams.filename, service.CutParams{DX: cutParams.dX, DY: cutParams.dY, Mode: service.ModeGrid, Format: imgprocessing.FormatJPEG}).
+					Return(fmt.Errorf("error on cut img: %w", imgprocessing.ErrSmallCut))
 			},
 			templateBehavior: func(te *MocktemplateExecutor, fileName string) {
-				te.EXPECT().ExecuteTemplate(&bytes.Buffer{}, "cutGood.html", fileName).Return(errors.New("some err"))
 			},
-			responseCode: http.StatusInternalServerError,
+			responseCode: http.StatusBadRequest,
 		},
 	}
 
@@ -152,6 +233,7 @@ func TestRouter_CutFile(t *testing.T) {
 			ss := service.NewMockSessionService(c)
 			fs := service.NewMockFileService(c)
 			te := NewMocktemplateExecutor(c)
+			allowErrorPages(te)
 			handler := Handler{
 				templates: te,
 				service:   service.Service{Files: fs, Session: ss},
@@ -186,6 +268,7 @@ func TestRouter_MainPage(t *testing.T) {
 		ctxRequest              func(r *http.Request, sessionID string) *http.Request
 		sessionServiceBehaviour func(mss *service.MockSessionService, sessionID string)
 		fileServiceBehaviour    func(mfs *service.MockFileService, session *service.Session)
+		presetServiceBehaviour  func(mps *service.MockPresetService, session *service.Session)
 		templateBehavior        func(te *MocktemplateExecutor)
 		responseCode            int
 	}{
@@ -197,6 +280,7 @@ func TestRouter_MainPage(t *testing.T) {
 			},
 			sessionServiceBehaviour: func(mss *service.MockSessionService, sessionID string) {
 				mss.EXPECT().Find(sessionID).Return(&service.Session{}, true)
+				mss.EXPECT().PopFlashes(&service.Session{}).Return([]service.Flash{{Key: "done.upload", Args: []string{"orig.jpg"}}}, nil)
 			},
 			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
 				mfs.EXPECT().GetFiles(&service.Session{}).Return([]service.MyFile{{
@@ -204,11 +288,24 @@ func TestRouter_MainPage(t *testing.T) {
 					Archive:      "orig.zip",
 				}}, nil)
 			},
+			presetServiceBehaviour: func(mps *service.MockPresetService, session *service.Session) {
+				mps.EXPECT().GetPresets(session).Return([]service.Preset{{
+					Name:      "square",
+					CutParams: service.CutParams{DX: 100, DY: 100, Mode: service.ModeGrid, Format: imgprocessing.FormatPNG},
+				}}, nil)
+			},
 			templateBehavior: func(te *MocktemplateExecutor) {
-				te.EXPECT().ExecuteTemplate(&bytes.Buffer{}, "home.html", []service.MyFile{{
-					OriginalFile: "orig.jpg",
-					Archive:      "orig.zip",
-				}}).Return(nil)
+				te.EXPECT().ExecuteTemplate(&bytes.Buffer{}, i18n.Default, "home.html", homePage{
+					Files: []service.MyFile{{
+						OriginalFile: "orig.jpg",
+						Archive:      "orig.zip",
+					}},
+					Presets: []service.Preset{{
+						Name:      "square",
+						CutParams: service.CutParams{DX: 100, DY: 100, Mode: service.ModeGrid, Format: imgprocessing.FormatPNG},
+					}},
+					Flashes: []string{"File orig.jpg successfully uploaded."},
+				}).Return(nil)
 			},
 			responseCode: 200,
 		},
@@ -222,6 +319,8 @@ func TestRouter_MainPage(t *testing.T) {
 			},
 			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
 			},
+			presetServiceBehaviour: func(mps *service.MockPresetService, session *service.Session) {
+			},
 			templateBehavior: func(te *MocktemplateExecutor) {
 			},
 			responseCode: http.StatusInternalServerError,
@@ -237,6 +336,8 @@ func TestRouter_MainPage(t *testing.T) {
 			},
 			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
 			},
+			presetServiceBehaviour: func(mps *service.MockPresetService, session *service.Session) {
+			},
 			templateBehavior: func(te *MocktemplateExecutor) {
 			},
 			responseCode: http.StatusNotFound,
@@ -253,6 +354,27 @@ func TestRouter_MainPage(t *testing.T) {
 			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
 				mfs.EXPECT().GetFiles(&service.Session{}).Return(nil, errors.New("some service err"))
 			},
+			presetServiceBehaviour: func(mps *service.MockPresetService, session *service.Session) {
+			},
+			templateBehavior: func(te *MocktemplateExecutor) {
+			},
+			responseCode: http.StatusInternalServerError,
+		},
+		{
+			name:      "unable to get presets list",
+			sessionID: "some-session-id",
+			ctxRequest: func(r *http.Request, sessionID string) *http.Request {
+				return r.WithContext(context.WithValue(context.Background(), ctxSessionKey, sessionID))
+			},
+			sessionServiceBehaviour: func(mss *service.MockSessionService, sessionID string) {
+				mss.EXPECT().Find(sessionID).Return(&service.Session{}, true)
+			},
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().GetFiles(&service.Session{}).Return([]service.MyFile{}, nil)
+			},
+			presetServiceBehaviour: func(mps *service.MockPresetService, session *service.Session) {
+				mps.EXPECT().GetPresets(session).Return(nil, errors.New("some service err"))
+			},
 			templateBehavior: func(te *MocktemplateExecutor) {
 			},
 			responseCode: http.StatusInternalServerError,
@@ -265,6 +387,7 @@ func TestRouter_MainPage(t *testing.T) {
 			},
 			sessionServiceBehaviour: func(mss *service.MockSessionService, sessionID string) {
 				mss.EXPECT().Find(sessionID).Return(&service.Session{}, true)
+				mss.EXPECT().PopFlashes(&service.Session{}).Return(nil, nil)
 			},
 			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
 				mfs.EXPECT().GetFiles(&service.Session{}).Return([]service.MyFile{{
@@ -275,14 +398,19 @@ func TestRouter_MainPage(t *testing.T) {
 					Archive:      "2.zip",
 				}}, nil)
 			},
+			presetServiceBehaviour: func(mps *service.MockPresetService, session *service.Session) {
+				mps.EXPECT().GetPresets(session).Return(nil, nil)
+			},
 			templateBehavior: func(te *MocktemplateExecutor) {
-				te.EXPECT().ExecuteTemplate(&bytes.Buffer{}, "home.html", []service.MyFile{{
-					OriginalFile: "1.jpg",
-					Archive:      "1.zip",
-				}, {
-					OriginalFile: "2.jpg",
-					Archive:      "2.zip",
-				}}).Return(errors.New("some template execution error"))
+				te.EXPECT().ExecuteTemplate(&bytes.Buffer{}, i18n.Default, "home.html", homePage{
+					Files: []service.MyFile{{
+						OriginalFile: "1.jpg",
+						Archive:      "1.zip",
+					}, {
+						OriginalFile: "2.jpg",
+						Archive:      "2.zip",
+					}},
+				}).Return(errors.New("some template execution error"))
 			},
 			responseCode: http.StatusInternalServerError,
 		},
@@ -295,14 +423,17 @@ func TestRouter_MainPage(t *testing.T) {
 
 			ss := service.NewMockSessionService(c)
 			fs := service.NewMockFileService(c)
+			ps := service.NewMockPresetService(c)
 			te := NewMocktemplateExecutor(c)
+			allowErrorPages(te)
 			handler := Handler{
 				templates: te,
-				service:   service.Service{Files: fs, Session: ss},
+				service:   service.Service{Files: fs, Session: ss, Presets: ps},
 			}
 
 			tc.sessionServiceBehaviour(ss, tc.sessionID)
 			tc.fileServiceBehaviour(fs, &service.Session{})
+			tc.presetServiceBehaviour(ps, &service.Session{})
 			tc.templateBehavior(te)
 
 			w := httptest.NewRecorder()
@@ -402,6 +533,22 @@ func TestRouter_DownloadFile(t *testing.T) {
 			},
 			responseCode: http.StatusInternalServerError,
 		},
+		{
+			name:        "archive not found",
+			sessionID:   "some-session-id",
+			fileName:    "filename.jpg",
+			formContent: map[string]string{"fileName": "filename.jpg"},
+			ctxRequest: func(r *http.Request, sessionID string) *http.Request {
+				return r.WithContext(context.WithValue(context.Background(), ctxSessionKey, sessionID))
+			},
+			sessionServiceBehaviour: func(mss *service.MockSessionService, sessionID string) {
+				mss.EXPECT().Find(sessionID).Return(&service.Session{}, true)
+			},
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session, fileName string) {
+				mfs.EXPECT().GetArchiveName(&service.Session{}, fileName).Return("", service.ErrFileNotFound)
+			},
+			responseCode: http.StatusNotFound,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -412,6 +559,7 @@ func TestRouter_DownloadFile(t *testing.T) {
 			ss := service.NewMockSessionService(c)
 			fs := service.NewMockFileService(c)
 			te := NewMocktemplateExecutor(c)
+			allowErrorPages(te)
 			handler := Handler{
 				templates: te,
 				service:   service.Service{Files: fs, Session: ss},
@@ -462,6 +610,7 @@ func TestRouter_UploadFile(t *testing.T) {
 			},
 			sessionServiceBehaviour: func(mss *service.MockSessionService, sessionID string) {
 				mss.EXPECT().Find(sessionID).Return(&service.Session{}, true)
+				mss.EXPECT().AddFlash(&service.Session{}, service.Flash{Key: "done.upload", Args: []string{"test.jpg"}}).Return(nil)
 			},
 			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session, referenceFile io.Reader, fileName string) {
 				mfs.EXPECT().UploadFile(&service.Session{}, gomock.Any(), fileName).Do(func(s *service.Session, uploadingFile io.Reader, fileName string) {
@@ -474,9 +623,8 @@ func TestRouter_UploadFile(t *testing.T) {
 				}).Return(nil)
 			},
 			templateBehavior: func(te *MocktemplateExecutor, fileName string) {
-				te.EXPECT().ExecuteTemplate(&bytes.Buffer{}, "uploadGood.html", fileName).Return(nil)
 			},
-			responseCode: http.StatusOK,
+			responseCode: http.StatusSeeOther,
 		},
 		{
 			name:        "no ctx value",
@@ -547,7 +695,7 @@ func TestRouter_UploadFile(t *testing.T) {
 			},
 			templateBehavior: func(te *MocktemplateExecutor, fileName string) {
 			},
-			responseCode: http.StatusBadRequest,
+			responseCode: http.StatusUnsupportedMediaType,
 		},
 		{
 			name:        "service error",
@@ -576,7 +724,7 @@ func TestRouter_UploadFile(t *testing.T) {
 			responseCode: http.StatusInternalServerError,
 		},
 		{
-			name:        "template error",
+			name:        "not an image",
 			sessionID:   "some-session-id",
 			attachFile:  true,
 			fileName:    "test.jpg",
@@ -588,19 +736,11 @@ func TestRouter_UploadFile(t *testing.T) {
 				mss.EXPECT().Find(sessionID).Return(&service.Session{}, true)
 			},
 			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session, referenceFile io.Reader, fileName string) {
-				mfs.EXPECT().UploadFile(&service.Session{}, gomock.Any(), fileName).Do(func(s *service.Session, uploadingFile io.Reader, fileName string) {
-					referenceBytes, err := io.ReadAll(referenceFile)
-					assert.Equal(t, err, nil)
-					incomingBytes, err := io.ReadAll(uploadingFile)
-					assert.Equal(t, err, nil)
-					md5.Sum(referenceBytes)
-					assert.Equal(t, md5.Sum(incomingBytes), md5.Sum(referenceBytes))
-				}).Return(nil)
+				mfs.EXPECT().UploadFile(&service.Session{}, gomock.Any(), fileName).Return(fmt.Errorf("%w: bad header", service.ErrNotImage))
 			},
 			templateBehavior: func(te *MocktemplateExecutor, fileName string) {
-				te.EXPECT().ExecuteTemplate(&bytes.Buffer{}, "uploadGood.html", fileName).Return(errors.New("some template error"))
 			},
-			responseCode: http.StatusInternalServerError,
+			responseCode: http.StatusUnsupportedMediaType,
 		},
 	}
 	for _, tc := range testCases {
@@ -611,6 +751,7 @@ func TestRouter_UploadFile(t *testing.T) {
 			ss := service.NewMockSessionService(c)
 			fs := service.NewMockFileService(c)
 			te := NewMocktemplateExecutor(c)
+			allowErrorPages(te)
 			handler := Handler{
 				templates: te,
 				service:   service.Service{Files: fs, Session: ss},
@@ -679,14 +820,14 @@ func TestRouter_DeleteFile(t *testing.T) {
 			},
 			sessionServiceBehaviour: func(mss *service.MockSessionService, sessionID string) {
 				mss.EXPECT().Find(sessionID).Return(&service.Session{}, true)
+				mss.EXPECT().AddFlash(&service.Session{}, service.Flash{Key: "done.delete", Args: []string{"filename.jpg"}}).Return(nil)
 			},
 			fileServiceBehaviour: func(mfs *service.MockFileService, fileName string) {
 				mfs.EXPECT().DeleteFile(&service.Session{}, fileName).Return(nil)
 			},
 			templateBehavior: func(te *MocktemplateExecutor, fileName string) {
-				te.EXPECT().ExecuteTemplate(&bytes.Buffer{}, "deleteGood.html", fileName).Return(nil)
 			},
-			responseCode: http.StatusOK,
+			responseCode: http.StatusSeeOther,
 		},
 		{
 			name:        "no ctx value",
@@ -756,25 +897,6 @@ func TestRouter_DeleteFile(t *testing.T) {
 			},
 			responseCode: http.StatusInternalServerError,
 		},
-		{
-			name:        "template error",
-			sessionID:   "some-session-id",
-			fileName:    "filename.jpg",
-			formContent: map[string]string{"fileName": "filename.jpg"},
-			ctxRequest: func(r *http.Request, sessionID string) *http.Request {
-				return r.WithContext(context.WithValue(context.Background(), ctxSessionKey, sessionID))
-			},
-			sessionServiceBehaviour: func(mss *service.MockSessionService, sessionID string) {
-				mss.EXPECT().Find(sessionID).Return(&service.Session{}, true)
-			},
-			fileServiceBehaviour: func(mfs *service.MockFileService, fileName string) {
-				mfs.EXPECT().DeleteFile(&service.Session{}, fileName).Return(nil)
-			},
-			templateBehavior: func(te *MocktemplateExecutor, fileName string) {
-				te.EXPECT().ExecuteTemplate(&bytes.Buffer{}, "deleteGood.html", fileName).Return(errors.New("template error"))
-			},
-			responseCode: http.StatusInternalServerError,
-		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -784,6 +906,7 @@ func TestRouter_DeleteFile(t *testing.T) {
 			ss := service.NewMockSessionService(c)
 			fs := service.NewMockFileService(c)
 			te := NewMocktemplateExecutor(c)
+			allowErrorPages(te)
 			handler := Handler{
 				templates: te,
 				service:   service.Service{Files: fs, Session: ss},
@@ -810,3 +933,242 @@ func TestRouter_DeleteFile(t *testing.T) {
 		})
 	}
 }
+
+func TestRouter_StitchFile(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		contentType          string
+		body                 string // "PK\x03\x04" if empty
+		format               string
+		fileServiceBehaviour func(mfs *service.MockFileService, session *service.Session)
+		responseCode         int
+	}{
+		{
+			name:        "ok",
+			contentType: "application/zip",
+			format:      "png",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().StitchFile(session, gomock.Any(), gomock.Any(), "tiles.zip", imgprocessing.FormatPNG).Return("test.jpg", nil)
+			},
+			responseCode: http.StatusOK,
+		},
+		{
+			name:        "zip sent as octet-stream",
+			contentType: "application/octet-stream",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().StitchFile(session, gomock.Any(), gomock.Any(), "tiles.zip", imgprocessing.FormatJPEG).Return("test.jpg", nil)
+			},
+			responseCode: http.StatusOK,
+		},
+		{
+			name:        "not a zip",
+			contentType: "application/zip",
+			body:        "\xff\xd8\xff\xe0 jpeg",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+			},
+			responseCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "too short",
+			contentType: "application/zip",
+			body:        "PK",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+			},
+			responseCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "unknown format",
+			contentType: "application/zip",
+			format:      "gif",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+			},
+			responseCode: http.StatusBadRequest,
+		},
+		{
+			name:        "bad grid",
+			contentType: "application/x-zip-compressed",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().StitchFile(session, gomock.Any(), gomock.Any(), "tiles.zip", imgprocessing.FormatJPEG).
+					Return("", fmt.Errorf("error on stitch archive: %w", imgprocessing.ErrBadGrid))
+			},
+			responseCode: http.StatusBadRequest,
+		},
+		{
+			name:        "too big",
+			contentType: "application/zip",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().StitchFile(session, gomock.Any(), gomock.Any(), "tiles.zip", imgprocessing.FormatJPEG).
+					Return("", imgprocessing.ErrImageTooBig)
+			},
+			responseCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "service error",
+			contentType: "application/zip",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().StitchFile(session, gomock.Any(), gomock.Any(), "tiles.zip", imgprocessing.FormatJPEG).
+					Return("", service.ErrFS)
+			},
+			responseCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			ss := service.NewMockSessionService(c)
+			fs := service.NewMockFileService(c)
+			handler := Handler{
+				service: service.Service{Files: fs, Session: ss},
+			}
+
+			ss.EXPECT().Find("some-session-id").Return(&service.Session{}, true)
+			tc.fileServiceBehaviour(fs, &service.Session{})
+
+			buf := bytes.Buffer{}
+			multipartWriter := multipart.NewWriter(&buf)
+			header := make(textproto.MIMEHeader)
+			header.Set("content-disposition", `form-data; name="archive"; filename="tiles.zip"`)
+			header.Set("content-type", tc.contentType)
+
+			partWriter, err := multipartWriter.CreatePart(header)
+			assert.Equal(t, err, nil)
+			body := tc.body
+			if body == "" {
+				body = "PK\x03\x04"
+			}
+			_, err = partWriter.Write([]byte(body))
+			assert.Equal(t, err, nil)
+			assert.Equal(t, multipartWriter.WriteField("format", tc.format), nil)
+			multipartWriter.Close()
+
+			r, _ := http.NewRequest(http.MethodPost, "/stitch", &buf)
+			r.Header.Add("Content-Type", multipartWriter.FormDataContentType())
+			r = r.WithContext(context.WithValue(context.Background(), ctxSessionKey, "some-session-id"))
+
+			w := httptest.NewRecorder()
+			handler.StitchFile(w, r)
+			assert.Equal(t, w.Result().StatusCode, tc.responseCode)
+		})
+	}
+}
+
+func TestRouter_PreviewFile(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		query                string
+		fileServiceBehaviour func(mfs *service.MockFileService, session *service.Session)
+		responseCode         int
+	}{
+		{
+			name:  "ok",
+			query: "fileName=test.jpg&mode=grid&dX=32&dY=32&size=200",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().PreviewFile(session, "test.jpg", gomock.Any(), 200).
+					Return(imgprocessing.RenderPreview(image.NewNRGBA(image.Rect(0, 0, 64, 64)), nil, imgprocessing.PreviewOptions{}), nil)
+			},
+			responseCode: http.StatusOK,
+		},
+		{
+			name:  "file not found",
+			query: "fileName=test.jpg&mode=grid&dX=32&dY=32",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().PreviewFile(session, "test.jpg", gomock.Any(), imgprocessing.DefaultPreviewSize).
+					Return(nil, service.ErrFileNotFound)
+			},
+			responseCode: http.StatusNotFound,
+		},
+		{
+			name:  "bad params",
+			query: "fileName=test.jpg&mode=guides&guidesX=5000",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().PreviewFile(session, "test.jpg", gomock.Any(), imgprocessing.DefaultPreviewSize).
+					Return(nil, imgprocessing.ErrBadGuides)
+			},
+			responseCode: http.StatusBadRequest,
+		},
+		{
+			name:                 "bad size",
+			query:                "fileName=test.jpg&size=0",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {},
+			responseCode:         http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			ss := service.NewMockSessionService(c)
+			fs := service.NewMockFileService(c)
+			handler := Handler{
+				service: service.Service{Files: fs, Session: ss},
+			}
+
+			ss.EXPECT().Find("some-session-id").Return(&service.Session{}, true).AnyTimes()
+			tc.fileServiceBehaviour(fs, &service.Session{})
+
+			r, _ := http.NewRequest(http.MethodGet, "/preview?"+tc.query, nil)
+			r = r.WithContext(context.WithValue(context.Background(), ctxSessionKey, "some-session-id"))
+
+			w := httptest.NewRecorder()
+			handler.PreviewFile(w, r)
+			assert.Equal(t, w.Result().StatusCode, tc.responseCode)
+		})
+	}
+}
+
+func TestRouter_Thumbnail(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		query                string
+		fileServiceBehaviour func(mfs *service.MockFileService, session *service.Session)
+		responseCode         int
+	}{
+		{
+			name:  "ok",
+			query: "fileName=test.jpg",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().GetThumbnail(session, "test.jpg").Return([]byte("png"), nil)
+			},
+			responseCode: http.StatusOK,
+		},
+		{
+			name:  "file not found",
+			query: "fileName=test.jpg",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().GetThumbnail(session, "test.jpg").Return(nil, service.ErrFileNotFound)
+			},
+			responseCode: http.StatusNotFound,
+		},
+		{
+			name:                 "no file name",
+			query:                "",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {},
+			responseCode:         http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			ss := service.NewMockSessionService(c)
+			fs := service.NewMockFileService(c)
+			handler := Handler{
+				service: service.Service{Files: fs, Session: ss},
+			}
+
+			ss.EXPECT().Find("some-session-id").Return(&service.Session{}, true).AnyTimes()
+			tc.fileServiceBehaviour(fs, &service.Session{})
+
+			r, _ := http.NewRequest(http.MethodGet, "/thumbnail?"+tc.query, nil)
+			r = r.WithContext(context.WithValue(context.Background(), ctxSessionKey, "some-session-id"))
+
+			w := httptest.NewRecorder()
+			handler.Thumbnail(w, r)
+			assert.Equal(t, w.Result().StatusCode, tc.responseCode)
+		})
+	}
+}
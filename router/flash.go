@@ -0,0 +1,41 @@
+package router
+
+import (
+	"log"
+	"net/http"
+
+	"imgcutter/service"
+)
+
+// flash remembers outcome of action for the main page and sends client there,
+// so refreshing the page does not repeat the action.
+func (h *Handler) flash(w http.ResponseWriter, r *http.Request, s *service.Session, key string, args ...string) {
+	if err := h.service.Session.AddFlash(s, service.Flash{Key: key, Args: args}); err != nil {
+		// действие уже выполнено, без сообщения о нём можно обойтись
+		log.Printf("unable to add flash: %v", err)
+	}
+
+	h.redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// flashMessages takes flash messages of session translated into locale of request.
+func (h *Handler) flashMessages(r *http.Request, s *service.Session) []string {
+	flashes, err := h.service.Session.PopFlashes(s)
+	if err != nil {
+		log.Printf("unable to get flashes: %v", err)
+		return nil
+	}
+
+	var out []string
+
+	for _, f := range flashes {
+		args := make([]any, len(f.Args))
+		for i, a := range f.Args {
+			args[i] = a
+		}
+
+		out = append(out, h.t(r, f.Key, args...))
+	}
+
+	return out
+}
@@ -1,34 +1,66 @@
 package router
 
 import (
+	"fmt"
 	"html/template"
 	"io"
+	"io/fs"
 	"net/http"
 	"path/filepath"
 
 	"imgcutter/service"
+	"imgcutter/static"
 )
 
 type Handler struct {
 	templates templateExecutor
 	service   service.Service
+	app       fs.FS // files of single page frontend
+	assets    fs.FS // scripts, styles and icons of pages
+	opts      Options
 }
 
 //go:generate mockgen -source=handler.go -destination=mock_template.go -package=router
 type templateExecutor interface {
-	ExecuteTemplate(wr io.Writer, name string, data any) error
+	// ExecuteTemplate renders template name translated into locale.
+	ExecuteTemplate(wr io.Writer, locale string, name string, data any) error
 }
 
-func NewRouter(s service.Service) (*Handler, error) {
-	templates, err := template.New("home.html").Funcs(template.FuncMap{
-		"base": filepath.Base,
-	}).ParseGlob("static/templates/*.html")
+// templateFuncs are available in every template.
+var templateFuncs = template.FuncMap{
+	"base":     filepath.Base,
+	"byteSize": byteSize,
+}
+
+func NewRouter(s service.Service, opts Options) (*Handler, error) {
+	templatesFS, err := loadFS(static.Templates, "templates", opts.OverrideDir)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := parseTemplates(messages, templatesFS)
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := loadFS(static.App, "app", opts.OverrideDir)
+	if err != nil {
+		return nil, err
+	}
+
+	assets, err := loadFS(static.Assets, "assets", opts.OverrideDir)
 	if err != nil {
 		return nil, err
 	}
+
+	opts.BasePath = cleanBasePath(opts.BasePath)
+
 	return &Handler{
 		templates: templates,
 		service:   s,
+		app:       app,
+		assets:    assets,
+		opts:      opts,
 	}, nil
 }
 
@@ -38,9 +70,47 @@ func (h *Handler) GetHTTPHandler() http.Handler {
 	mux.HandleFunc("/cut", h.CutFile)
 	mux.HandleFunc("/download", h.DownloadFile)
 	mux.HandleFunc("/delete", h.DeleteFile)
-	mux.HandleFunc("/favicon.ico", h.favicon)
+	mux.HandleFunc("/favicon.ico", serveFiles(h.assets))
+	mux.Handle("/static/", http.StripPrefix("/static/", serveFiles(h.assets)))
 	mux.HandleFunc("/terminate", h.TerminateSession)
 	mux.HandleFunc("/upload", h.UploadFile)
-	handler := h.Logging(h.ManageSession(mux.ServeHTTP))
-	return handler
+	mux.HandleFunc("/stitch", h.StitchFile)
+	mux.HandleFunc("/sprite", h.SpriteFiles)
+	mux.HandleFunc("/preview", h.PreviewFile)
+	mux.HandleFunc("/thumbnail", h.Thumbnail)
+	mux.HandleFunc("/tiles", h.ListTiles)
+	mux.HandleFunc("/tile", h.Tile)
+	mux.HandleFunc("/gallery", h.Gallery)
+	mux.HandleFunc("/presets/save", h.SavePreset)
+	mux.HandleFunc("/presets/delete", h.DeletePreset)
+	mux.HandleFunc("/lang", h.SetLanguage)
+	mux.HandleFunc("/api/csrf", h.APICSRF)
+	mux.HandleFunc("/api/messages", h.APIMessages)
+	mux.HandleFunc("/api/files", h.APIFiles)
+	mux.HandleFunc("/api/cut", h.APICut)
+	mux.HandleFunc("/api/presets", h.APIPresets)
+	mux.HandleFunc("/api/archive", h.APIArchive)
+	mux.Handle("/app/", http.StripPrefix("/app/", serveFiles(h.app)))
+	handler := h.Logging(h.ManageSession(h.Localize(h.CheckCSRF(mux.ServeHTTP))))
+	if h.opts.BasePath == "" {
+		return handler
+	}
+
+	return h.mount(handler)
+}
+
+// byteSize formats size like 1.5 MB.
+func byteSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGT"[exp])
 }
@@ -0,0 +1,130 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+
+	"imgcutter/i18n"
+	"imgcutter/static"
+)
+
+const (
+	langCookie            = "lang"
+	langCookieLife        = 365 * 24 * 60 * 60 // in seconds
+	ctxLocaleKey   ctxStr = "locale"
+)
+
+// messages are catalogs built into binary, so they are checked once on start.
+var messages = mustLoadMessages()
+
+func mustLoadMessages() *i18n.Bundle {
+	bundle, err := i18n.Load(mustSub(static.Locales, "locales"))
+	if err == nil {
+		err = bundle.Check()
+	}
+
+	if err != nil {
+		panic("message catalogs: " + err.Error())
+	}
+
+	return bundle
+}
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+
+	return sub
+}
+
+// localizedTemplates are parsed once per locale, "t" of each translates into its locale.
+type localizedTemplates map[string]*template.Template
+
+// parseTemplates parses "*.html" of fsys for every locale of bundle.
+func parseTemplates(bundle *i18n.Bundle, fsys fs.FS) (localizedTemplates, error) {
+	lt := localizedTemplates{}
+
+	for _, locale := range bundle.Locales() {
+		locale := locale
+
+		t, err := template.New("home.html").Funcs(templateFuncs).Funcs(template.FuncMap{
+			"t": func(key string, args ...any) (string, error) {
+				return bundle.Translate(locale, key, args...)
+			},
+			"lang":    func() string { return locale },
+			"locales": bundle.Locales,
+		}).ParseFS(fsys, "*.html")
+		if err != nil {
+			return nil, err
+		}
+
+		lt[locale] = t
+	}
+
+	return lt, nil
+}
+
+func (lt localizedTemplates) ExecuteTemplate(wr io.Writer, locale string, name string, data any) error {
+	t, ok := lt[locale]
+	if !ok {
+		t = lt[i18n.Default]
+	}
+
+	return t.ExecuteTemplate(wr, name, data)
+}
+
+// Localize puts locale of client into request context: the one chosen with
+// "lang" cookie, or the best match of Accept-Language header.
+func (h *Handler) Localize(f http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		locale := messages.Match(r.Header.Get("Accept-Language"))
+		if c, err := r.Cookie(langCookie); err == nil && messages.Has(c.Value) {
+			locale = c.Value
+		}
+
+		w.Header().Set("Content-Language", locale)
+		w.Header().Add("Vary", "Accept-Language, Cookie")
+
+		f(w, r.WithContext(context.WithValue(r.Context(), ctxLocaleKey, locale)))
+	}
+}
+
+// locale returns locale of request set by Localize.
+func (h *Handler) locale(r *http.Request) string {
+	if locale, ok := r.Context().Value(ctxLocaleKey).(string); ok {
+		return locale
+	}
+
+	return i18n.Default
+}
+
+// t translates message of key into locale of request.
+func (h *Handler) t(r *http.Request, key string, args ...any) string {
+	return messages.T(h.locale(r), key, args...)
+}
+
+// SetLanguage remembers locale "set" of query in cookie and returns to main page.
+func (h *Handler) SetLanguage(w http.ResponseWriter, r *http.Request) {
+	locale := r.URL.Query().Get("set")
+	if !messages.Has(locale) {
+		h.writeError(w, r, withStatus(http.StatusBadRequest, errors.New(h.t(r, "error.unsupported_locale", locale))))
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     langCookie,
+		Value:    locale,
+		Path:     h.prefix(r) + "/",
+		MaxAge:   langCookieLife,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	h.redirect(w, r, "/", http.StatusSeeOther)
+}
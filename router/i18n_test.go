@@ -0,0 +1,105 @@
+package router
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"imgcutter/i18n"
+	"imgcutter/static"
+
+	"github.com/magiconair/properties/assert"
+)
+
+// Every template must render in every locale, unknown message keys fail rendering.
+func TestTemplates_Locales(t *testing.T) {
+	templates, err := parseTemplates(messages, mustSub(static.Templates, "templates"))
+	assert.Equal(t, err, nil)
+
+	for _, locale := range messages.Locales() {
+		for name, data := range testPages() {
+			t.Run(locale+"/"+name, func(t *testing.T) {
+				b := bytes.Buffer{}
+				assert.Equal(t, templates.ExecuteTemplate(&b, locale, name, data), nil)
+				assert.Equal(t, strings.Contains(b.String(), `<html lang="`+locale+`">`), true)
+			})
+		}
+	}
+
+	// неизвестная локаль показывается на языке по умолчанию
+	b := bytes.Buffer{}
+	assert.Equal(t, templates.ExecuteTemplate(&b, "de", "error.html", errorPage{Status: http.StatusNotFound, Home: "./"}), nil)
+	assert.Equal(t, strings.Contains(b.String(), `<html lang="`+i18n.Default+`">`), true)
+}
+
+func TestRouter_Localize(t *testing.T) {
+	testCases := []struct {
+		name           string
+		acceptLanguage string
+		cookie         string
+		locale         string
+	}{
+		{name: "default", locale: "en"},
+		{name: "accept language", acceptLanguage: "ru-RU,ru;q=0.9,en;q=0.8", locale: "ru"},
+		{name: "cookie over header", acceptLanguage: "ru", cookie: "en", locale: "en"},
+		{name: "unsupported cookie", acceptLanguage: "ru", cookie: "de", locale: "ru"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := Handler{}
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tc.acceptLanguage)
+			}
+			if tc.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: langCookie, Value: tc.cookie})
+			}
+
+			var locale string
+
+			w := httptest.NewRecorder()
+			handler.Localize(func(w http.ResponseWriter, r *http.Request) {
+				locale = handler.locale(r)
+			})(w, r)
+
+			assert.Equal(t, locale, tc.locale)
+			assert.Equal(t, w.Result().Header.Get("Content-Language"), tc.locale)
+		})
+	}
+}
+
+func TestRouter_SetLanguage(t *testing.T) {
+	testCases := []struct {
+		name         string
+		url          string
+		responseCode int
+		cookie       string
+	}{
+		{name: "ok", url: "/lang?set=ru", responseCode: http.StatusSeeOther, cookie: "ru"},
+		{name: "unsupported", url: "/lang?set=de", responseCode: http.StatusBadRequest},
+		{name: "missing", url: "/lang", responseCode: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := Handler{opts: Options{BasePath: "/cutter"}}
+
+			w := httptest.NewRecorder()
+			handler.SetLanguage(w, httptest.NewRequest(http.MethodGet, "http://example.com"+tc.url, nil))
+			assert.Equal(t, w.Result().StatusCode, tc.responseCode)
+
+			if tc.cookie == "" {
+				assert.Equal(t, len(w.Result().Cookies()), 0)
+				return
+			}
+
+			c := w.Result().Cookies()[0]
+			assert.Equal(t, c.Name, langCookie)
+			assert.Equal(t, c.Value, tc.cookie)
+			assert.Equal(t, c.Path, "/cutter/")
+			assert.Equal(t, w.Result().Header.Get("Location"), "/cutter/")
+		})
+	}
+}
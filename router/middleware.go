@@ -30,7 +30,7 @@ func (h *Handler) ManageSession(f http.HandlerFunc) http.HandlerFunc {
 		if !ok {
 			log.Printf("creating new session")
 			session = h.service.Session.New().String() // side-effect: new session entry in service.Session
-			h.setSessionCookie(session, w)
+			h.setSessionCookie(session, w, r)
 		}
 
 		log.Printf("working session: %s", session)
@@ -60,14 +60,15 @@ func (h *Handler) checkSessionCookie(r *http.Request) (sessionUUID string, ok bo
 	return session, true
 }
 
-func (h *Handler) setSessionCookie(session string, w http.ResponseWriter) {
+func (h *Handler) setSessionCookie(session string, w http.ResponseWriter, r *http.Request) {
 	newCookie := http.Cookie{
 		Name:     sessionID,
 		Value:    session,
-		Path:     "/",
+		Path:     h.prefix(r) + "/",
 		MaxAge:   cookieLife, // in seconds
 		Secure:   false,
 		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode, // формы других сайтов приходят без куки
 	}
 	http.SetCookie(w, &newCookie)
 	log.Printf("sent cookie: %s", newCookie.String())
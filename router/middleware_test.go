@@ -127,6 +127,7 @@ func TestRouter_MiddelwareLogging(t *testing.T) {
 			ss := service.NewMockSessionService(c)
 			fs := service.NewMockFileService(c)
 			te := NewMocktemplateExecutor(c)
+			allowErrorPages(te)
 			handler := Handler{
 				templates: te,
 				service:   service.Service{Files: fs, Session: ss},
@@ -182,7 +183,7 @@ func TestRouter_MiddelwareManageSession(t *testing.T) {
 			},
 			requestSetup: func(r *http.Request) {
 			},
-			logOutRegexp: "session cookie not found\n.+creating new session\n.+sent cookie: SESSID=00000000-0000-0000-0000-000000000000; Path=/; HttpOnly\n.+working session: 00000000-0000-0000-0000-000000000000",
+			logOutRegexp: "session cookie not found\n.+creating new session\n.+sent cookie: SESSID=00000000-0000-0000-0000-000000000000; Path=/; HttpOnly; SameSite=Lax\n.+working session: 00000000-0000-0000-0000-000000000000",
 		},
 		{
 			name:                 "request with valid cookie",
@@ -209,7 +210,7 @@ func TestRouter_MiddelwareManageSession(t *testing.T) {
 			requestSetup: func(r *http.Request) {
 				r.AddCookie(&http.Cookie{Name: sessionID, Value: "00000000-ffff-1111-eeee-555566667777"})
 			},
-			logOutRegexp: "creating new session\n.+sent cookie: SESSID=00000000-0000-0000-0000-000000000000; Path=/; HttpOnly\n.+working session: 00000000-0000-0000-0000-000000000000",
+			logOutRegexp: "creating new session\n.+sent cookie: SESSID=00000000-0000-0000-0000-000000000000; Path=/; HttpOnly; SameSite=Lax\n.+working session: 00000000-0000-0000-0000-000000000000",
 		},
 	}
 	for _, tc := range testCases {
@@ -224,6 +225,7 @@ func TestRouter_MiddelwareManageSession(t *testing.T) {
 			ss := service.NewMockSessionService(c)
 			fs := service.NewMockFileService(c)
 			te := NewMocktemplateExecutor(c)
+			allowErrorPages(te)
 			handler := Handler{
 				templates: te,
 				service:   service.Service{Files: fs, Session: ss},
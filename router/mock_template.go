@@ -35,15 +35,15 @@ func (m *MocktemplateExecutor) EXPECT() *MocktemplateExecutorMockRecorder {
 }
 
 // ExecuteTemplate mocks base method.
-func (m *MocktemplateExecutor) ExecuteTemplate(wr io.Writer, name string, data any) error {
+func (m *MocktemplateExecutor) ExecuteTemplate(wr io.Writer, locale, name string, data any) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ExecuteTemplate", wr, name, data)
+	ret := m.ctrl.Call(m, "ExecuteTemplate", wr, locale, name, data)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ExecuteTemplate indicates an expected call of ExecuteTemplate.
-func (mr *MocktemplateExecutorMockRecorder) ExecuteTemplate(wr, name, data interface{}) *gomock.Call {
+func (mr *MocktemplateExecutorMockRecorder) ExecuteTemplate(wr, locale, name, data interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteTemplate", reflect.TypeOf((*MocktemplateExecutor)(nil).ExecuteTemplate), wr, name, data)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteTemplate", reflect.TypeOf((*MocktemplateExecutor)(nil).ExecuteTemplate), wr, locale, name, data)
 }
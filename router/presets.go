@@ -0,0 +1,74 @@
+package router
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"imgcutter/service"
+)
+
+func (h *Handler) SavePreset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.writeError(w, r, badRequest("error parsing form", err))
+		return
+	}
+
+	params, err := parseCutParams(r.PostForm)
+	if err != nil {
+		h.writeError(w, r, badRequest("error parsing cut params", err))
+		return
+	}
+
+	session, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	preset := service.Preset{Name: r.PostForm.Get("name"), CutParams: params}
+	if err := h.service.Presets.SavePreset(session, preset); err != nil {
+		h.writeError(w, r, fmt.Errorf("unable to save preset: %w", err))
+		return
+	}
+
+	log.Printf("preset %q saved", preset.Name)
+	h.flash(w, r, session, "done.preset_save", preset.Name)
+}
+
+func (h *Handler) DeletePreset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.writeError(w, r, badRequest("error parsing form", err))
+		return
+	}
+
+	if !r.PostForm.Has("name") {
+		h.writeError(w, r, h.missingField(r, "name"))
+		return
+	}
+	name := r.PostForm.Get("name")
+
+	session, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	if err := h.service.Presets.DeletePreset(session, name); err != nil {
+		h.writeError(w, r, fmt.Errorf("unable to delete preset: %w", err))
+		return
+	}
+
+	log.Printf("preset %q deleted", name)
+	h.flash(w, r, session, "done.preset_delete", name)
+}
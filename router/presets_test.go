@@ -0,0 +1,175 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"imgcutter/imgprocessing"
+	"imgcutter/service"
+
+	"github.com/golang/mock/gomock"
+	"github.com/magiconair/properties/assert"
+)
+
+func TestRouter_SavePreset(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		formContent            map[string]string
+		presetServiceBehaviour func(mps *service.MockPresetService, session *service.Session)
+		responseCode           int
+	}{
+		{
+			name:        "ok",
+			formContent: map[string]string{"name": "square", "dX": "100", "dY": "100", "format": "png"},
+			presetServiceBehaviour: func(mps *service.MockPresetService, session *service.Session) {
+				mps.EXPECT().SavePreset(session, service.Preset{
+					Name:      "square",
+					CutParams: service.CutParams{DX: 100, DY: 100, Mode: service.ModeGrid, Format: imgprocessing.FormatPNG},
+				}).Return(nil)
+			},
+			responseCode: http.StatusSeeOther,
+		},
+		{
+			name:        "err parsing int",
+			formContent: map[string]string{"name": "square", "dX": "sto", "dY": "100"},
+			presetServiceBehaviour: func(mps *service.MockPresetService, session *service.Session) {
+			},
+			responseCode: http.StatusBadRequest,
+		},
+		{
+			name:        "unknown format",
+			formContent: map[string]string{"name": "square", "dX": "100", "dY": "100", "format": "bmp"},
+			presetServiceBehaviour: func(mps *service.MockPresetService, session *service.Session) {
+			},
+			responseCode: http.StatusBadRequest,
+		},
+		{
+			name:        "built-in preset",
+			formContent: map[string]string{"name": "instagram", "dX": "1080", "dY": "1080"},
+			presetServiceBehaviour: func(mps *service.MockPresetService, session *service.Session) {
+				mps.EXPECT().SavePreset(session, gomock.Any()).Return(service.ErrPresetReadOnly)
+			},
+			responseCode: http.StatusConflict,
+		},
+		{
+			name:        "service error",
+			formContent: map[string]string{"name": "square", "dX": "100", "dY": "100"},
+			presetServiceBehaviour: func(mps *service.MockPresetService, session *service.Session) {
+				mps.EXPECT().SavePreset(session, gomock.Any()).Return(errors.New("some service error"))
+			},
+			responseCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			ss := service.NewMockSessionService(c)
+			ps := service.NewMockPresetService(c)
+			handler := Handler{
+				service: service.Service{Session: ss, Presets: ps},
+			}
+
+			ss.EXPECT().Find("random-uuid").Return(&service.Session{}, true).AnyTimes()
+			if tc.responseCode == http.StatusSeeOther {
+				ss.EXPECT().AddFlash(&service.Session{}, service.Flash{Key: "done.preset_save", Args: []string{"square"}}).Return(nil)
+			}
+			tc.presetServiceBehaviour(ps, &service.Session{})
+
+			params := url.Values{}
+			for k, v := range tc.formContent {
+				params.Add(k, v)
+			}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPost, "/presets/save", bytes.NewBufferString(params.Encode()))
+			r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+			r = r.WithContext(context.WithValue(context.Background(), ctxSessionKey, "random-uuid"))
+
+			handler.SavePreset(w, r)
+
+			assert.Equal(t, w.Result().StatusCode, tc.responseCode)
+		})
+	}
+}
+
+func TestRouter_DeletePreset(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		formContent            map[string]string
+		presetServiceBehaviour func(mps *service.MockPresetService, session *service.Session)
+		responseCode           int
+	}{
+		{
+			name:        "ok",
+			formContent: map[string]string{"name": "square"},
+			presetServiceBehaviour: func(mps *service.MockPresetService, session *service.Session) {
+				mps.EXPECT().DeletePreset(session, "square").Return(nil)
+			},
+			responseCode: http.StatusSeeOther,
+		},
+		{
+			name:        "missing field name",
+			formContent: map[string]string{},
+			presetServiceBehaviour: func(mps *service.MockPresetService, session *service.Session) {
+			},
+			responseCode: http.StatusBadRequest,
+		},
+		{
+			name:        "not found",
+			formContent: map[string]string{"name": "unknown"},
+			presetServiceBehaviour: func(mps *service.MockPresetService, session *service.Session) {
+				mps.EXPECT().DeletePreset(session, "unknown").Return(service.ErrPresetNotFound)
+			},
+			responseCode: http.StatusNotFound,
+		},
+		{
+			name:        "built-in preset",
+			formContent: map[string]string{"name": "instagram"},
+			presetServiceBehaviour: func(mps *service.MockPresetService, session *service.Session) {
+				mps.EXPECT().DeletePreset(session, "instagram").Return(service.ErrPresetReadOnly)
+			},
+			responseCode: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			ss := service.NewMockSessionService(c)
+			ps := service.NewMockPresetService(c)
+			handler := Handler{
+				service: service.Service{Session: ss, Presets: ps},
+			}
+
+			ss.EXPECT().Find("random-uuid").Return(&service.Session{}, true).AnyTimes()
+			if tc.responseCode == http.StatusSeeOther {
+				ss.EXPECT().AddFlash(&service.Session{}, service.Flash{Key: "done.preset_delete", Args: []string{"square"}}).Return(nil)
+			}
+			tc.presetServiceBehaviour(ps, &service.Session{})
+
+			params := url.Values{}
+			for k, v := range tc.formContent {
+				params.Add(k, v)
+			}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPost, "/presets/delete", bytes.NewBufferString(params.Encode()))
+			r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+			r = r.WithContext(context.WithValue(context.Background(), ctxSessionKey, "random-uuid"))
+
+			handler.DeletePreset(w, r)
+
+			assert.Equal(t, w.Result().StatusCode, tc.responseCode)
+		})
+	}
+}
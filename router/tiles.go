@@ -0,0 +1,146 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+
+	"imgcutter/imgprocessing"
+)
+
+// galleryPage is data of "gallery.html" template.
+type galleryPage struct {
+	FileName string
+	Width    int
+	Height   int
+	Tiles    []galleryTile
+}
+
+// galleryTile is placed on page in percents of image size, so gallery scales with page.
+type galleryTile struct {
+	imgprocessing.ManifestTile
+	Left, Top, PercentWidth, PercentHeight float64
+}
+
+func newGalleryPage(fileName string, set *imgprocessing.TileSet) galleryPage {
+	page := galleryPage{
+		FileName: fileName,
+		Width:    set.Width,
+		Height:   set.Height,
+		Tiles:    make([]galleryTile, 0, len(set.Tiles)),
+	}
+
+	percent := func(v, of int) float64 { return float64(v) * 100 / float64(of) }
+
+	for _, t := range set.Tiles {
+		page.Tiles = append(page.Tiles, galleryTile{
+			ManifestTile:  t,
+			Left:          percent(t.X, set.Width),
+			Top:           percent(t.Y, set.Height),
+			PercentWidth:  percent(t.Width, set.Width),
+			PercentHeight: percent(t.Height, set.Height),
+		})
+	}
+
+	return page
+}
+
+// ListTiles answers with json layout of tiles of cut file "fileName" of query.
+func (h *Handler) ListTiles(w http.ResponseWriter, r *http.Request) {
+	fileName := r.URL.Query().Get("fileName")
+	if fileName == "" {
+		h.writeError(w, r, h.missingField(r, "fileName"))
+		return
+	}
+
+	s, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	set, err := h.service.Files.ListTiles(s, fileName)
+	if err != nil {
+		h.writeError(w, r, fmt.Errorf("error listing tiles: %w", err))
+		return
+	}
+
+	b, err := json.Marshal(set)
+	if err != nil {
+		h.writeError(w, r, fmt.Errorf("error encoding tiles: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+// Tile serves a single tile "tile" of cut file "fileName" of query,
+// as attachment if query has "download".
+func (h *Handler) Tile(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	fileName, tileName := query.Get("fileName"), query.Get("tile")
+
+	if fileName == "" {
+		h.writeError(w, r, h.missingField(r, "fileName"))
+		return
+	}
+
+	if tileName == "" {
+		h.writeError(w, r, h.missingField(r, "tile"))
+		return
+	}
+
+	s, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	tile, err := h.service.Files.ReadTile(s, fileName, tileName)
+	if err != nil {
+		h.writeError(w, r, fmt.Errorf("error reading tile %q: %w", tileName, err))
+		return
+	}
+
+	if query.Has("download") {
+		w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(path.Base(tileName)))
+	}
+
+	w.Header().Set("Content-Type", http.DetectContentType(tile))
+	w.Write(tile)
+}
+
+// Gallery shows tiles of cut file "fileName" of query in their places.
+func (h *Handler) Gallery(w http.ResponseWriter, r *http.Request) {
+	fileName := r.URL.Query().Get("fileName")
+	if fileName == "" {
+		h.writeError(w, r, h.missingField(r, "fileName"))
+		return
+	}
+
+	s, err := h.session(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	set, err := h.service.Files.ListTiles(s, fileName)
+	if err != nil {
+		h.writeError(w, r, fmt.Errorf("error listing tiles: %w", err))
+		return
+	}
+
+	b := bytes.Buffer{}
+
+	if err := h.templates.ExecuteTemplate(&b, h.locale(r), "gallery.html", newGalleryPage(fileName, set)); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b.Bytes())
+}
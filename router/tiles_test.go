@@ -0,0 +1,192 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"imgcutter/i18n"
+	"imgcutter/imgprocessing"
+	"imgcutter/service"
+
+	"github.com/golang/mock/gomock"
+	"github.com/magiconair/properties/assert"
+)
+
+func testTileSet() *imgprocessing.TileSet {
+	return &imgprocessing.TileSet{
+		Width:  100,
+		Height: 50,
+		Tiles: []imgprocessing.ManifestTile{
+			{TileEntry: imgprocessing.TileEntry{File: "test_1x1.png"}, Width: 50, Height: 50},
+			{TileEntry: imgprocessing.TileEntry{File: "test_1x2.png", Col: 1, Index: 1}, X: 50, Width: 50, Height: 50},
+		},
+	}
+}
+
+func TestRouter_ListTiles(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		query                string
+		fileServiceBehaviour func(mfs *service.MockFileService, session *service.Session)
+		responseCode         int
+	}{
+		{
+			name:  "ok",
+			query: "fileName=test.jpg",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().ListTiles(session, "test.jpg").Return(testTileSet(), nil)
+			},
+			responseCode: http.StatusOK,
+		},
+		{
+			name:  "not cut",
+			query: "fileName=test.jpg",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().ListTiles(session, "test.jpg").Return(nil, service.ErrFileNotFound)
+			},
+			responseCode: http.StatusNotFound,
+		},
+		{
+			name:  "not a grid",
+			query: "fileName=test.jpg",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().ListTiles(session, "test.jpg").
+					Return(nil, fmt.Errorf("%w: unexpected file %q", imgprocessing.ErrBadArchive, "test.dzi"))
+			},
+			responseCode: http.StatusBadRequest,
+		},
+		{
+			name:                 "no file name",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {},
+			responseCode:         http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			ss := service.NewMockSessionService(c)
+			fs := service.NewMockFileService(c)
+			handler := Handler{
+				service: service.Service{Files: fs, Session: ss},
+			}
+
+			ss.EXPECT().Find("some-session-id").Return(&service.Session{}, true).AnyTimes()
+			tc.fileServiceBehaviour(fs, &service.Session{})
+
+			r, _ := http.NewRequest(http.MethodGet, "/tiles?"+tc.query, nil)
+			r = r.WithContext(context.WithValue(context.Background(), ctxSessionKey, "some-session-id"))
+
+			w := httptest.NewRecorder()
+			handler.ListTiles(w, r)
+			assert.Equal(t, w.Result().StatusCode, tc.responseCode)
+
+			if tc.responseCode == http.StatusOK {
+				var set imgprocessing.TileSet
+				assert.Equal(t, json.NewDecoder(w.Body).Decode(&set), nil)
+				assert.Equal(t, set, *testTileSet())
+			}
+		})
+	}
+}
+
+func TestRouter_Tile(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		query                string
+		fileServiceBehaviour func(mfs *service.MockFileService, session *service.Session)
+		responseCode         int
+		disposition          string
+	}{
+		{
+			name:  "ok",
+			query: "fileName=test.jpg&tile=test_1x2.png",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().ReadTile(session, "test.jpg", "test_1x2.png").Return([]byte("\x89PNG\r\n\x1a\n"), nil)
+			},
+			responseCode: http.StatusOK,
+		},
+		{
+			name:  "download",
+			query: "fileName=test.jpg&tile=tiles/test_1x2.png&download=1",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().ReadTile(session, "test.jpg", "tiles/test_1x2.png").Return([]byte("\x89PNG\r\n\x1a\n"), nil)
+			},
+			responseCode: http.StatusOK,
+			disposition:  `attachment; filename="test_1x2.png"`,
+		},
+		{
+			name:  "no such tile",
+			query: "fileName=test.jpg&tile=manifest.json",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {
+				mfs.EXPECT().ReadTile(session, "test.jpg", "manifest.json").Return(nil, service.ErrFileNotFound)
+			},
+			responseCode: http.StatusNotFound,
+		},
+		{
+			name:                 "no tile",
+			query:                "fileName=test.jpg",
+			fileServiceBehaviour: func(mfs *service.MockFileService, session *service.Session) {},
+			responseCode:         http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			ss := service.NewMockSessionService(c)
+			fs := service.NewMockFileService(c)
+			handler := Handler{
+				service: service.Service{Files: fs, Session: ss},
+			}
+
+			ss.EXPECT().Find("some-session-id").Return(&service.Session{}, true).AnyTimes()
+			tc.fileServiceBehaviour(fs, &service.Session{})
+
+			r, _ := http.NewRequest(http.MethodGet, "/tile?"+tc.query, nil)
+			r = r.WithContext(context.WithValue(context.Background(), ctxSessionKey, "some-session-id"))
+
+			w := httptest.NewRecorder()
+			handler.Tile(w, r)
+			assert.Equal(t, w.Result().StatusCode, tc.responseCode)
+			assert.Equal(t, w.Result().Header.Get("Content-Disposition"), tc.disposition)
+		})
+	}
+}
+
+func TestRouter_Gallery(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	ss := service.NewMockSessionService(c)
+	fs := service.NewMockFileService(c)
+	te := NewMocktemplateExecutor(c)
+	allowErrorPages(te)
+	handler := Handler{
+		templates: te,
+		service:   service.Service{Files: fs, Session: ss},
+	}
+
+	ss.EXPECT().Find("some-session-id").Return(&service.Session{}, true)
+	fs.EXPECT().ListTiles(&service.Session{}, "test.jpg").Return(testTileSet(), nil)
+	te.EXPECT().ExecuteTemplate(gomock.Any(), i18n.Default, "gallery.html", gomock.Any()).
+		Do(func(_ any, _, _ string, data any) {
+			page := data.(galleryPage)
+			assert.Equal(t, len(page.Tiles), 2)
+			assert.Equal(t, page.Tiles[1].Left, 50.0)
+			assert.Equal(t, page.Tiles[1].PercentHeight, 100.0)
+		}).Return(nil)
+
+	r, _ := http.NewRequest(http.MethodGet, "/gallery?fileName=test.jpg", nil)
+	r = r.WithContext(context.WithValue(context.Background(), ctxSessionKey, "some-session-id"))
+
+	w := httptest.NewRecorder()
+	handler.Gallery(w, r)
+	assert.Equal(t, w.Result().StatusCode, http.StatusOK)
+}
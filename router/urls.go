@@ -0,0 +1,98 @@
+package router
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Options configure how handler is deployed.
+type Options struct {
+	// BasePath is path prefix handler is mounted under, like "/cutter". Empty means root.
+	BasePath string
+	// TrustProxy makes handler honour X-Forwarded-Proto, X-Forwarded-Host and X-Forwarded-Prefix
+	// headers when generating links. Enable it only behind a proxy that sets them.
+	TrustProxy bool
+	// OverrideDir holds custom branding: files of its "templates", "assets" and "app" subdirectories
+	// replace built-in ones of the same name. Empty means built-in files only.
+	OverrideDir string
+}
+
+// cleanBasePath turns "cutter/", "/cutter" or "/cutter/" into "/cutter", root into "".
+func cleanBasePath(p string) string {
+	if p == "" {
+		return ""
+	}
+
+	p = path.Clean("/" + p)
+	if p == "/" {
+		return ""
+	}
+
+	return p
+}
+
+// prefix returns path all pages of handler are under as seen by client, without trailing slash.
+func (h *Handler) prefix(r *http.Request) string {
+	p := h.opts.BasePath
+	if h.opts.TrustProxy {
+		// прокси мог отрезать свой префикс перед тем, как передать запрос
+		p = cleanBasePath(r.Header.Get("X-Forwarded-Prefix")) + p
+	}
+
+	return p
+}
+
+// absURL builds absolute link to path of handler as seen by client.
+func (h *Handler) absURL(r *http.Request, p string) string {
+	scheme, host := "http", r.Host
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	if h.opts.TrustProxy {
+		// в заголовках может быть цепочка прокси, первым идёт ближайший к клиенту
+		if proto := firstValue(r.Header.Get("X-Forwarded-Proto")); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+
+		if fwdHost := firstValue(r.Header.Get("X-Forwarded-Host")); fwdHost != "" {
+			host = fwdHost
+		}
+	}
+
+	return scheme + "://" + host + h.prefix(r) + p
+}
+
+func firstValue(header string) string {
+	v, _, _ := strings.Cut(header, ",")
+	return strings.TrimSpace(v)
+}
+
+// redirect sends client to path of handler, like "/" for main page.
+// Location is absolute only behind trusted proxy, otherwise it is a path
+// and Host header of request is not echoed back.
+func (h *Handler) redirect(w http.ResponseWriter, r *http.Request, p string, code int) {
+	location := h.prefix(r) + p
+	if h.opts.TrustProxy {
+		location = h.absURL(r, p)
+	}
+
+	http.Redirect(w, r, location, code)
+}
+
+// mount serves next under BasePath, "/cutter" is redirected to "/cutter/", everything outside is not found.
+func (h *Handler) mount(next http.Handler) http.Handler {
+	stripped := http.StripPrefix(h.opts.BasePath, next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == h.opts.BasePath:
+			h.redirect(w, r, "/", http.StatusMovedPermanently)
+		case strings.HasPrefix(r.URL.Path, h.opts.BasePath+"/"):
+			stripped.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
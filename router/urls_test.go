@@ -0,0 +1,182 @@
+package router
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"imgcutter/i18n"
+	"imgcutter/service"
+	"imgcutter/static"
+
+	"github.com/golang/mock/gomock"
+	"github.com/magiconair/properties/assert"
+)
+
+func TestCleanBasePath(t *testing.T) {
+	for in, want := range map[string]string{"": "", "/": "", "cutter": "/cutter", "/cutter/": "/cutter", "/a//b/": "/a/b"} {
+		assert.Equal(t, cleanBasePath(in), want, in)
+	}
+}
+
+func TestRouter_BasePath(t *testing.T) {
+	testCases := []struct {
+		name         string
+		opts         Options
+		url          string
+		headers      map[string]string
+		newSession   bool
+		responseCode int
+		location     string
+		cookiePath   string
+	}{
+		{
+			name:         "root",
+			url:          "/presets/save",
+			newSession:   true,
+			responseCode: http.StatusFound,
+			location:     "/",
+			cookiePath:   "/",
+		},
+		{
+			name:         "prefixed",
+			opts:         Options{BasePath: "/cutter"},
+			url:          "/cutter/presets/save",
+			newSession:   true,
+			responseCode: http.StatusFound,
+			location:     "/cutter/",
+			cookiePath:   "/cutter/",
+		},
+		{
+			name: "behind proxy",
+			opts: Options{BasePath: "/cutter", TrustProxy: true},
+			url:  "/cutter/presets/save",
+			headers: map[string]string{
+				"X-Forwarded-Proto":  "https",
+				"X-Forwarded-Host":   "images.example.org, 10.0.0.1",
+				"X-Forwarded-Prefix": "/tools/",
+			},
+			newSession:   true,
+			responseCode: http.StatusFound,
+			location:     "https://images.example.org/tools/cutter/",
+			cookiePath:   "/tools/cutter/",
+		},
+		{
+			name:         "trusted proxy without headers",
+			opts:         Options{BasePath: "/cutter", TrustProxy: true},
+			url:          "/cutter/presets/save",
+			newSession:   true,
+			responseCode: http.StatusFound,
+			location:     "http://example.com/cutter/",
+			cookiePath:   "/cutter/",
+		},
+		{
+			name: "untrusted proxy headers",
+			opts: Options{BasePath: "/cutter"},
+			url:  "/cutter/presets/save",
+			headers: map[string]string{
+				"X-Forwarded-Proto":  "https",
+				"X-Forwarded-Host":   "evil.example.org",
+				"X-Forwarded-Prefix": "/tools",
+			},
+			newSession:   true,
+			responseCode: http.StatusFound,
+			location:     "/cutter/",
+			cookiePath:   "/cutter/",
+		},
+		{
+			name:         "prefix without slash",
+			opts:         Options{BasePath: "/cutter"},
+			url:          "/cutter",
+			responseCode: http.StatusMovedPermanently,
+			location:     "/cutter/",
+		},
+		{
+			name:         "outside of prefix",
+			opts:         Options{BasePath: "/cutter"},
+			url:          "/cutterx/presets/save",
+			responseCode: http.StatusNotFound,
+		},
+		{
+			name:         "prefixed app",
+			opts:         Options{BasePath: "/cutter"},
+			url:          "/cutter/app/",
+			newSession:   true,
+			responseCode: http.StatusOK,
+			cookiePath:   "/cutter/",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			app, err := fs.Sub(static.App, "app")
+			assert.Equal(t, err, nil)
+
+			ss := service.NewMockSessionService(c)
+			handler := Handler{
+				service: service.Service{Session: ss},
+				app:     app,
+				opts:    Options{BasePath: cleanBasePath(tc.opts.BasePath), TrustProxy: tc.opts.TrustProxy},
+			}
+
+			if tc.newSession {
+				ss.EXPECT().New().Return(&service.Session{})
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "http://example.com"+tc.url, nil)
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+
+			w := httptest.NewRecorder()
+			handler.GetHTTPHandler().ServeHTTP(w, r)
+			assert.Equal(t, w.Result().StatusCode, tc.responseCode)
+			assert.Equal(t, w.Result().Header.Get("Location"), tc.location)
+
+			if tc.newSession {
+				assert.Equal(t, w.Result().Cookies()[0].Path, tc.cookiePath)
+			}
+		})
+	}
+}
+
+// testPages returns data for every template.
+func testPages() map[string]any {
+	return map[string]any{
+		"home.html": homePage{
+			Files:     []service.MyFile{{OriginalFile: "temp/id/a.png", Archive: "temp/id/a.zip", Uploaded: time.Now()}},
+			Presets:   []service.Preset{{Name: "icons", CutParams: service.CutParams{DX: 64, DY: 64}}},
+			Flashes:   []string{"File a.png successfully uploaded."},
+			CSRFToken: "test-token",
+		},
+		"gallery.html": newGalleryPage("temp/id/a.png", testTileSet()),
+		"error.html":   errorPage{Status: http.StatusNotFound, Title: "Not found", Cause: "file not found", Home: "./"},
+	}
+}
+
+// Pages may be served under any prefix, so every link of templates must be relative.
+func TestTemplates_RelativeLinks(t *testing.T) {
+	templates, err := parseTemplates(messages, mustSub(static.Templates, "templates"))
+	assert.Equal(t, err, nil)
+
+	link := regexp.MustCompile(`(?:href|action|src)="([^"]*)"`)
+
+	for name, data := range testPages() {
+		t.Run(name, func(t *testing.T) {
+			b := bytes.Buffer{}
+			assert.Equal(t, templates.ExecuteTemplate(&b, i18n.Default, name, data), nil)
+
+			for _, m := range link.FindAllStringSubmatch(b.String(), -1) {
+				absolute := strings.HasPrefix(m[1], "/") || strings.Contains(m[1], "://")
+				assert.Equal(t, absolute, false, m[1])
+			}
+		})
+	}
+}
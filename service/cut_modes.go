@@ -0,0 +1,271 @@
+package service
+
+import (
+	"archive/zip"
+	"fmt"
+	"image"
+	"log"
+
+	"imgcutter/imgprocessing"
+)
+
+// autoNameTemplate is default for ModeAuto, there are no rows and columns.
+const autoNameTemplate = "{name}_{index}.{ext}"
+
+// packFunc writes pieces of cut image into archive.
+type packFunc func(dest *zip.Writer) error
+
+// cutImage splits img according to params.Mode. Pieces are written only by returned packFunc,
+// so nothing is created on disk if image can not be cut.
+func cutImage(img image.Image, params CutParams, opts imgprocessing.PackOptions) (packFunc, error) {
+	switch params.Mode {
+	case ModeGrid, ModeGuides:
+		images, err := cutGrid(img, params)
+		if err != nil {
+			return nil, err
+		}
+
+		if params.Resize != "" {
+			opts.Canvas = imgprocessing.GridBounds(images).Size()
+		}
+
+		return func(dest *zip.Writer) error {
+			manifest, err := imgprocessing.PackImages(dest, images, opts)
+			if err != nil {
+				return err
+			}
+
+			log.Printf("packed %d tiles, skipped %d blank ones", len(manifest.Tiles), len(manifest.Skipped))
+
+			if d := manifest.Dedup; d != nil {
+				log.Printf("stored %d unique tiles, %d duplicates saved %d bytes", d.Unique, d.Duplicates, d.BytesSaved)
+			}
+
+			return nil
+		}, nil
+
+	case ModeSprite:
+		images, err := cutGrid(img, params)
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := imgprocessing.Layout(images, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		sprites := make([]imgprocessing.Sprite, 0, len(entries))
+		for _, e := range entries {
+			tile := images[e.Row][e.Col]
+			if opts.SkipBlank != nil && imgprocessing.IsBlank(tile, *opts.SkipBlank) {
+				continue
+			}
+
+			sprites = append(sprites, imgprocessing.Sprite{Name: e.File, Image: tile})
+		}
+
+		return func(dest *zip.Writer) error {
+			return packSprites(dest, sprites, opts.Name, params)
+		}, nil
+
+	case ModeAuto:
+		method, err := imgprocessing.ParseDetectMethod(params.Detect)
+		if err != nil {
+			return nil, err
+		}
+
+		bg, err := imgprocessing.ParseBackground(params.Background, params.Tolerance)
+		if err != nil {
+			return nil, err
+		}
+
+		rects, err := imgprocessing.DetectSprites(img, method, bg)
+		if err != nil {
+			return nil, err
+		}
+
+		pieces, err := imgprocessing.CutRects(img, rects)
+		if err != nil {
+			return nil, err
+		}
+
+		images := [][]image.Image{pieces}
+
+		if params.Naming == "" {
+			if opts.Naming, err = imgprocessing.ParseNameTemplate(autoNameTemplate); err != nil {
+				return nil, err
+			}
+		}
+
+		log.Printf("detected %d sprites", len(rects))
+
+		// все спрайты идут одной строкой, прямоугольники попадают в манифест
+		return func(dest *zip.Writer) error {
+			_, err := imgprocessing.PackImages(dest, images, opts)
+			return err
+		}, nil
+
+	case ModeRegions:
+		pieces, err := imgprocessing.CutRegions(img, params.Regions)
+		if err != nil {
+			return nil, err
+		}
+
+		if params.Naming == "" {
+			if opts.Naming, err = imgprocessing.ParseNameTemplate(imgprocessing.RegionsNameTemplate); err != nil {
+				return nil, err
+			}
+		}
+
+		opts.Labels = imgprocessing.RegionNames(params.Regions)
+
+		// имена проверяем до создания архива
+		if _, err := imgprocessing.Layout([][]image.Image{pieces}, opts); err != nil {
+			return nil, err
+		}
+
+		return func(dest *zip.Writer) error {
+			_, err := imgprocessing.PackImages(dest, [][]image.Image{pieces}, opts)
+			return err
+		}, nil
+
+	case ModeDZI:
+		dzi := params.deepZoomOptions(opts.Name)
+		if err := dzi.Validate(); err != nil {
+			return nil, err
+		}
+
+		return func(dest *zip.Writer) error {
+			levels, tiles, err := imgprocessing.PackDeepZoom(dest, img, dzi)
+			if err != nil {
+				return err
+			}
+
+			log.Printf("packed %d levels of deep zoom pyramid, %d tiles", levels, tiles)
+
+			return nil
+		}, nil
+
+	case ModeXYZ:
+		slippy := params.slippyOptions()
+		if err := slippy.Validate(); err != nil {
+			return nil, err
+		}
+
+		if native := imgprocessing.NativeZoom(img.Bounds().Size()); slippy.MaxZoom > native || slippy.MinZoom > native {
+			return nil, fmt.Errorf("%w: image allows zoom up to %d", imgprocessing.ErrBadZoom, native)
+		}
+
+		return func(dest *zip.Writer) error {
+			zooms, tiles, err := imgprocessing.PackSlippyTiles(dest, img, slippy)
+			if err != nil {
+				return err
+			}
+
+			log.Printf("packed %d zoom levels of slippy map, %d tiles", zooms, tiles)
+
+			return nil
+		}, nil
+	}
+
+	return nil, ErrUnknownMode
+}
+
+// cutGrid cuts img by DX and DY, or by guides in ModeGuides, and resizes pieces if asked.
+func cutGrid(img image.Image, params CutParams) ([][]image.Image, error) {
+	var (
+		images [][]image.Image
+		err    error
+	)
+
+	if params.Mode == ModeGuides {
+		images, err = imgprocessing.CutGuides(img, params.guides())
+	} else {
+		images, err = imgprocessing.CutImage(img, params.DX, params.DY)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return imgprocessing.ResizeGrid(images, params.resizeOptions())
+}
+
+func packSprites(dest *zip.Writer, sprites []imgprocessing.Sprite, name string, params CutParams) error {
+	atlas, err := imgprocessing.PackSpriteSheet(dest, sprites, imgprocessing.SpriteOptions{
+		Name:    name,
+		Format:  params.Format,
+		Padding: params.Padding,
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("packed %d sprites into %dx%d px sheet", len(atlas.Frames), atlas.Meta.Size.W, atlas.Meta.Size.H)
+
+	return nil
+}
+
+// previewRects returns rectangles of tiles img would be cut into by params,
+// and whether it is a grid with the rest at edges.
+func previewRects(img image.Image, params CutParams) ([]image.Rectangle, bool, error) {
+	switch params.Mode {
+	case ModeGrid, ModeSprite, ModeGuides:
+		var (
+			images [][]image.Image
+			err    error
+		)
+
+		if params.Mode == ModeGuides {
+			images, err = imgprocessing.CutGuides(img, params.guides())
+		} else {
+			images, err = imgprocessing.CutImage(img, params.DX, params.DY)
+		}
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		return imgprocessing.GridRects(images), params.Mode != ModeGuides, nil
+
+	case ModeAuto:
+		method, err := imgprocessing.ParseDetectMethod(params.Detect)
+		if err != nil {
+			return nil, false, err
+		}
+
+		bg, err := imgprocessing.ParseBackground(params.Background, params.Tolerance)
+		if err != nil {
+			return nil, false, err
+		}
+
+		rects, err := imgprocessing.DetectSprites(img, method, bg)
+
+		return rects, false, err
+
+	case ModeRegions:
+		pieces, err := imgprocessing.CutRegions(img, params.Regions)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return imgprocessing.GridRects([][]image.Image{pieces}), false, nil
+
+	case ModeDZI, ModeXYZ:
+		// тайлы самого подробного уровня
+		size := imgprocessing.SlippyTileSize
+		if params.Mode == ModeDZI {
+			size = params.TileSize
+		}
+
+		images, err := imgprocessing.CutImage(img, size, size)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return imgprocessing.GridRects(images), true, nil
+	}
+
+	return nil, false, ErrUnknownMode
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"imgcutter/imgprocessing"
+)
+
+var (
+	ErrUnknownMode = errors.New("unknown cut mode")
+	ErrBadPadding  = errors.New("padding must not be negative")
+)
+
+// CutMode selects an algorithm used to split an image into pieces.
+type CutMode string
+
+const (
+	// ModeGrid cuts image into pieces of DX x DY px starting from top left corner.
+	ModeGrid CutMode = "grid"
+	// ModeSprite cuts image like ModeGrid and packs pieces into a sprite sheet with css and json atlas.
+	ModeSprite CutMode = "sprite"
+	// ModeAuto finds sprites separated by background and cuts each one by its bounding box.
+	ModeAuto CutMode = "auto"
+	// ModeDZI builds Deep Zoom Image pyramid of TileSize px tiles for OpenSeadragon and alike.
+	ModeDZI CutMode = "dzi"
+	// ModeXYZ builds 256 px slippy map tiles "{z}/{x}/{y}" for Leaflet and alike.
+	ModeXYZ CutMode = "xyz"
+	// ModeGuides cuts image by given lines or sizes of columns and rows, see imgprocessing.Guides.
+	ModeGuides CutMode = "guides"
+	// ModeRegions cuts named rectangles, every one is saved under its own name.
+	ModeRegions CutMode = "regions"
+)
+
+// usesGrid tells if mode cuts by DX and DY.
+func (m CutMode) usesGrid() bool {
+	return m == ModeGrid || m == ModeSprite
+}
+
+// hasTiles tells if archive of mode has manifest with tiles to list and browse.
+// Sprite sheets and tile pyramids are laid out by their own formats.
+func (m CutMode) hasTiles() bool {
+	return m == ModeGrid || m == ModeGuides || m == ModeRegions || m == ModeAuto
+}
+
+// ParseCutMode returns ModeGrid for empty string.
+func ParseCutMode(s string) (CutMode, error) {
+	switch CutMode(s) {
+	case "", ModeGrid:
+		return ModeGrid, nil
+	case ModeSprite, ModeAuto, ModeDZI, ModeXYZ, ModeGuides, ModeRegions:
+		return CutMode(s), nil
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrUnknownMode, s)
+}
+
+// CutParams describes how an uploaded file should be cut and packed.
+type CutParams struct {
+	DX     int                  `json:"dX"`
+	DY     int                  `json:"dY"`
+	Mode   CutMode              `json:"mode"`
+	Format imgprocessing.Format `json:"format"`
+	Naming string               `json:"naming,omitempty"` // name template of tiles, see imgprocessing.NameTemplate
+
+	ManifestCSV bool `json:"manifestCSV,omitempty"` // add manifest.csv to archive
+	Dedup       bool `json:"dedup,omitempty"`       // store identical tiles once
+	Padding     int  `json:"padding,omitempty"`     // gap between sprites on sheet in px
+
+	// ModeAuto settings, see imgprocessing.DetectSprites, Background and Tolerance are used by SkipBlank too
+	Detect     string `json:"detect,omitempty"`     // "regions" or "gutters"
+	Background string `json:"background,omitempty"` // "auto", "transparent" or "#rrggbb"
+	Tolerance  int    `json:"tolerance,omitempty"`  // max difference of color channel from background
+	SkipBlank  bool   `json:"skipBlank,omitempty"`  // leave out tiles made only of background, "auto" means uniform ones
+
+	// ModeDZI settings, see imgprocessing.DeepZoomOptions
+	TileSize int `json:"tileSize,omitempty"`
+	Overlap  int `json:"overlap,omitempty"`
+
+	// ModeXYZ settings, see imgprocessing.SlippyOptions
+	MinZoom int  `json:"minZoom,omitempty"`
+	MaxZoom int  `json:"maxZoom,omitempty"` // 0 means native size of image
+	TMS     bool `json:"tms,omitempty"`
+
+	// ModeGuides settings
+	GuidesX []int `json:"guidesX,omitempty"`
+	GuidesY []int `json:"guidesY,omitempty"`
+	Columns []int `json:"columns,omitempty"` // widths of columns
+	Rows    []int `json:"rows,omitempty"`    // heights of rows
+
+	Regions []imgprocessing.Region `json:"regions,omitempty"` // ModeRegions settings
+
+	// output size of tiles in grid, sprite and guides modes, see imgprocessing.ResizeOptions
+	Resize       string `json:"resize,omitempty"` // "", "exact", "fit", "fill" or "max"
+	ResizeWidth  int    `json:"resizeWidth,omitempty"`
+	ResizeHeight int    `json:"resizeHeight,omitempty"`
+	Filter       string `json:"filter,omitempty"` // "nearest", "bilinear", "catmull-rom" or "lanczos"
+
+	// transform of source before cut, see imgprocessing.Transform
+	Crop   string  `json:"crop,omitempty"`   // "x,y,width,height"
+	Rotate int     `json:"rotate,omitempty"` // 90, 180 or 270 clockwise
+	Flip   string  `json:"flip,omitempty"`   // "horizontal" or "vertical"
+	Scale  float64 `json:"scale,omitempty"`
+}
+
+func (p CutParams) Validate() error {
+	if _, err := ParseCutMode(string(p.Mode)); err != nil {
+		return err
+	}
+
+	if _, err := imgprocessing.ParseFormat(string(p.Format)); err != nil {
+		return err
+	}
+
+	if _, err := imgprocessing.ParseNameTemplate(p.Naming); err != nil {
+		return err
+	}
+
+	if p.Padding < 0 {
+		return ErrBadPadding
+	}
+
+	if _, err := imgprocessing.ParseDetectMethod(p.Detect); err != nil {
+		return err
+	}
+
+	if _, err := imgprocessing.ParseBackground(p.Background, p.Tolerance); err != nil {
+		return err
+	}
+
+	if err := p.resizeOptions().Validate(); err != nil {
+		return err
+	}
+
+	// найденные спрайты не сетка, манифест не может описать их исходные и новые размеры сразу
+	if p.Mode == ModeAuto && p.Resize != "" {
+		return fmt.Errorf("%w: not supported in %s mode", imgprocessing.ErrBadResize, p.Mode)
+	}
+
+	transform, err := p.transform()
+	if err != nil {
+		return err
+	}
+
+	if err := transform.Validate(); err != nil {
+		return err
+	}
+
+	if p.Mode == ModeDZI {
+		if err := p.deepZoomOptions("").Validate(); err != nil {
+			return err
+		}
+	}
+
+	if p.Mode == ModeXYZ {
+		if err := p.slippyOptions().Validate(); err != nil {
+			return err
+		}
+	}
+
+	if p.Mode == ModeGuides {
+		if err := p.guides().Validate(); err != nil {
+			return err
+		}
+	}
+
+	if p.Mode == ModeRegions {
+		if err := imgprocessing.ValidateRegions(p.Regions); err != nil {
+			return err
+		}
+	}
+
+	if p.Mode.usesGrid() && (p.DX < imgprocessing.MinPieceSize || p.DY < imgprocessing.MinPieceSize) {
+		return imgprocessing.ErrSmallCut
+	}
+
+	return nil
+}
+
+// withDefaults fills empty mode and format (png for ModeXYZ), and tile size with overlap of ModeDZI.
+func (p CutParams) withDefaults() CutParams {
+	if p.Mode == "" {
+		p.Mode = ModeGrid
+	}
+
+	if p.Format == "" && p.Mode == ModeXYZ {
+		p.Format = imgprocessing.FormatPNG
+	}
+
+	if p.Format == "" {
+		p.Format = imgprocessing.FormatJPEG
+	}
+
+	if p.Mode == ModeDZI && p.TileSize == 0 {
+		p.TileSize = imgprocessing.DefaultDeepZoomTileSize
+		p.Overlap = imgprocessing.DefaultDeepZoomOverlap
+	}
+
+	return p
+}
+
+func (p CutParams) deepZoomOptions(name string) imgprocessing.DeepZoomOptions {
+	p = p.withDefaults()
+
+	return imgprocessing.DeepZoomOptions{
+		Name:     name,
+		TileSize: p.TileSize,
+		Overlap:  p.Overlap,
+		Format:   p.Format,
+	}
+}
+
+func (p CutParams) slippyOptions() imgprocessing.SlippyOptions {
+	p = p.withDefaults()
+
+	return imgprocessing.SlippyOptions{
+		MinZoom: p.MinZoom,
+		MaxZoom: p.MaxZoom,
+		TMS:     p.TMS,
+		Format:  p.Format,
+	}
+}
+
+func (p CutParams) resizeOptions() imgprocessing.ResizeOptions {
+	return imgprocessing.ResizeOptions{
+		Mode:   imgprocessing.ResizeMode(p.Resize),
+		Width:  p.ResizeWidth,
+		Height: p.ResizeHeight,
+		Filter: imgprocessing.Filter(p.Filter),
+	}
+}
+
+func (p CutParams) transform() (imgprocessing.Transform, error) {
+	crop, err := imgprocessing.ParseRect(p.Crop)
+	if err != nil {
+		return imgprocessing.Transform{}, err
+	}
+
+	return imgprocessing.Transform{
+		Crop:   crop,
+		Rotate: p.Rotate,
+		Flip:   imgprocessing.Flip(p.Flip),
+		Scale:  p.Scale,
+		Filter: imgprocessing.Filter(p.Filter),
+	}, nil
+}
+
+func (p CutParams) guides() imgprocessing.Guides {
+	return imgprocessing.Guides{X: p.GuidesX, Y: p.GuidesY, Columns: p.Columns, Rows: p.Rows}
+}
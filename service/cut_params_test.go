@@ -0,0 +1,40 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"imgcutter/imgprocessing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func Test_CutParams_ValidateResize(t *testing.T) {
+	tests := []struct {
+		name   string
+		params CutParams
+		err    error
+	}{
+		{
+			name:   "grid",
+			params: CutParams{Mode: ModeGrid, DX: 64, DY: 64, Resize: "fit", ResizeWidth: 32, ResizeHeight: 32},
+		},
+		{
+			name:   "auto without resize",
+			params: CutParams{Mode: ModeAuto},
+		},
+		{
+			name:   "auto",
+			params: CutParams{Mode: ModeAuto, Resize: "fit", ResizeWidth: 32, ResizeHeight: 32},
+			err:    imgprocessing.ErrBadResize,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.params.Validate()
+			assert.Equal(t, errors.Is(err, tc.err), true)
+			assert.Equal(t, err == nil, tc.err == nil)
+		})
+	}
+}
@@ -2,8 +2,10 @@ package service
 
 import (
 	"archive/zip"
+	"bytes"
 	"errors"
 	"fmt"
+	"image"
 	"io"
 	"io/fs"
 	"log"
@@ -23,6 +25,8 @@ var (
 	ErrFileNotFound = errors.New("file not found")
 	ErrFS           = errors.New("filesystem error")
 	ErrNilSession   = errors.New("nil session")
+	ErrNotImage     = errors.New("file is not an image")
+	ErrNoTiles      = errors.New("archive has no tiles to browse")
 )
 
 type MyFile struct {
@@ -31,8 +35,29 @@ type MyFile struct {
 
 	// Full-Name like path/Name.ext
 	Archive string // export to templates
+	Tiles   bool   // archive has manifest of tiles, see ListTiles
 
-	uploaded time.Time
+	Info     imgprocessing.ImageInfo
+	Size     int64 // bytes of OriginalFile
+	Uploaded time.Time
+
+	thumbnail []byte // png, computed on upload
+}
+
+// newMyFile describes decoded file and renders its thumbnail.
+func newMyFile(fileName string, img image.Image, format string, size int64) (MyFile, error) {
+	thumbnail := bytes.Buffer{}
+	if err := imgprocessing.Encode(&thumbnail, imgprocessing.Thumbnail(img, imgprocessing.DefaultThumbnailSize), imgprocessing.FormatPNG); err != nil {
+		return MyFile{}, fmt.Errorf("error encoding thumbnail: %w", err)
+	}
+
+	return MyFile{
+		OriginalFile: fileName,
+		Info:         imgprocessing.Describe(img, format),
+		Size:         size,
+		Uploaded:     time.Now(),
+		thumbnail:    thumbnail.Bytes(),
+	}, nil
 }
 
 // key type is Full-Name like path/Name.ext .
@@ -61,6 +86,15 @@ type Session struct {
 	id        uuid.UUID
 	fileMutex sync.Mutex // лочим на работу с мапой tempFiles и на всё из пакета "os" (Create, Open, Mkdir...)
 	files     tempFiles
+	sheets    map[string][]string // sprite sheet archive -> files packed into it, under fileMutex
+
+	presetMutex sync.Mutex
+	presets     map[string]Preset // user-defined presets by name
+
+	flashMutex sync.Mutex
+	flashes    []Flash // outcomes of actions not shown yet
+
+	csrfToken string // secret for forms of this session, see CSRFToken
 }
 
 // returns string presintation of session's id.
@@ -68,6 +102,11 @@ func (s *Session) String() string {
 	return s.id.String()
 }
 
+// returns token that must accompany every state-changing request of session.
+func (s *Session) CSRFToken() string {
+	return s.csrfToken
+}
+
 type fileManager struct {
 	sessionsMapMutex sync.Mutex
 	sessions         map[string]*Session
@@ -83,20 +122,35 @@ func (fm *fileManager) GetFiles(s *Session) ([]MyFile, error) {
 		output = append(output, f)
 	}
 
-	sort.Slice(output, func(i, j int) bool { return output[i].uploaded.After(output[j].uploaded) })
+	sort.Slice(output, func(i, j int) bool { return output[i].Uploaded.After(output[j].Uploaded) })
 
 	return output, nil
 }
 
-func (fm *fileManager) CutFile(s *Session, fileName string, dx int, dy int) error {
+func (fm *fileManager) CutFile(s *Session, fileName string, params CutParams) error {
 	if s == nil {
 		return ErrNilSession
 	}
 
+	params = params.withDefaults()
+	if _, err := ParseCutMode(string(params.Mode)); err != nil {
+		return err
+	}
+
+	naming, err := imgprocessing.ParseNameTemplate(params.Naming)
+	if err != nil {
+		return err
+	}
+
 	// дальше функция состоит почти полностью из работы с файлами
 	s.fileMutex.Lock()
 	defer s.fileMutex.Unlock()
 
+	// режем только загруженное в эту сессию, имя приходит от клиента
+	if _, ok := s.files[fileName]; !ok {
+		return ErrFileNotFound
+	}
+
 	// открываем изображение
 	img, format, err := imgprocessing.OpenImage(fileName)
 	if err != nil {
@@ -105,8 +159,53 @@ func (fm *fileManager) CutFile(s *Session, fileName string, dx int, dy int) erro
 
 	log.Printf("Decoded format is: %s", format)
 
+	source := imgprocessing.SourceInfo{
+		Name:   filepath.Base(fileName),
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+		Format: format,
+	}
+
+	// поворачиваем, обрезаем и масштабируем до нарезки
+	transform, err := params.transform()
+	if err == nil {
+		img, err = imgprocessing.ApplyTransform(img, transform)
+	}
+
+	if err != nil {
+		e := fmt.Errorf("error on transform img: %w", err)
+		log.Println(e)
+		return e
+	}
+
+	// archiveName = path + name, без расширениея
+	archiveName := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	packOptions := imgprocessing.PackOptions{
+		Name:        filepath.Base(archiveName),
+		Format:      params.Format,
+		Naming:      naming,
+		Source:      source,
+		Params:      params,
+		ManifestCSV: params.ManifestCSV,
+		Canvas:      img.Bounds().Size(),
+		Dedup:       params.Dedup,
+	}
+
+	if !transform.IsIdentity() {
+		packOptions.Transform = &transform
+	}
+
+	if params.SkipBlank {
+		bg, err := imgprocessing.ParseBackground(params.Background, params.Tolerance)
+		if err != nil {
+			return err
+		}
+
+		packOptions.SkipBlank = &bg
+	}
+
 	// режем изображение
-	images, err := imgprocessing.CutImage(img, dx, dy)
+	pack, err := cutImage(img, params, packOptions)
 	if err != nil {
 		e := fmt.Errorf("error on cut img: %w", err)
 		log.Println(e)
@@ -114,8 +213,6 @@ func (fm *fileManager) CutFile(s *Session, fileName string, dx int, dy int) erro
 	}
 
 	// создаём архив
-	// archiveName = path + name, без расширениея
-	archiveName := strings.TrimSuffix(fileName, filepath.Ext(fileName))
 	archive, err := os.Create(fmt.Sprintf("%s.zip", archiveName))
 
 	if err != nil {
@@ -130,14 +227,14 @@ func (fm *fileManager) CutFile(s *Session, fileName string, dx int, dy int) erro
 	defer zipWriter.Close()
 
 	// пакуем в архив
-	if err := imgprocessing.PackImages(zipWriter, images, filepath.Base(archiveName)); err != nil {
+	if err := pack(zipWriter); err != nil {
 		e := fmt.Errorf("error on create archive file: %w", err)
 		log.Println(e)
 		return e
 	}
 
 	// записываем путь архива в myFile
-	if err := fm.setArchivePath(s, fileName, archive.Name()); err != nil {
+	if err := fm.setArchivePath(s, fileName, archive.Name(), params.Mode.hasTiles()); err != nil {
 		e := fmt.Errorf("error on set archive path: %w", err)
 		log.Println(e)
 		return e
@@ -146,11 +243,177 @@ func (fm *fileManager) CutFile(s *Session, fileName string, dx int, dy int) erro
 	return nil
 }
 
+func (fm *fileManager) PreviewFile(s *Session, fileName string, params CutParams, maxSize int) (image.Image, error) {
+	if s == nil {
+		return nil, ErrNilSession
+	}
+
+	params = params.withDefaults()
+
+	s.fileMutex.Lock()
+	if _, ok := s.files[fileName]; !ok {
+		s.fileMutex.Unlock()
+		return nil, ErrFileNotFound
+	}
+
+	img, _, err := imgprocessing.OpenImage(fileName)
+	s.fileMutex.Unlock()
+
+	if err != nil {
+		return nil, fmt.Errorf("error processing image: %w", err)
+	}
+
+	transform, err := params.transform()
+	if err == nil {
+		img, err = imgprocessing.ApplyTransform(img, transform)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error on transform img: %w", err)
+	}
+
+	rects, edges, err := previewRects(img, params)
+	if err != nil {
+		return nil, fmt.Errorf("error on cut img: %w", err)
+	}
+
+	return imgprocessing.RenderPreview(img, rects, imgprocessing.PreviewOptions{MaxSize: maxSize, HighlightEdges: edges}), nil
+}
+
+func (fm *fileManager) StitchFile(s *Session, archive io.ReaderAt, size int64, archiveName string, format imgprocessing.Format) (string, error) {
+	if s == nil {
+		return "", ErrNilSession
+	}
+
+	zipReader, err := zip.NewReader(archive, size)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", imgprocessing.ErrBadArchive, err)
+	}
+
+	img, err := imgprocessing.Stitch(zipReader)
+	if err != nil {
+		e := fmt.Errorf("error on stitch archive: %w", err)
+		log.Println(e)
+		return "", e
+	}
+
+	s.fileMutex.Lock()
+	defer s.fileMutex.Unlock()
+
+	if err := createDirIfNotExist(fmt.Sprintf("temp/%s", s.String())); err != nil {
+		log.Println(err)
+		return "", ErrFS
+	}
+
+	baseName := strings.TrimSuffix(filepath.Base(archiveName), filepath.Ext(archiveName))
+	localFile, err := os.Create(fmt.Sprintf("temp/%s/%s_stitched.%s", s.String(), baseName, format.Ext()))
+	if err != nil {
+		log.Printf("error creating file: %s", err)
+		return "", ErrFS
+	}
+	defer localFile.Close()
+
+	encoded := bytes.Buffer{}
+	if err := imgprocessing.Encode(&encoded, img, format); err != nil {
+		log.Printf("error encoding stitched image: %s", err)
+		return "", ErrFS
+	}
+
+	if _, err := localFile.Write(encoded.Bytes()); err != nil {
+		log.Printf("error writing stitched image: %s", err)
+		return "", ErrFS
+	}
+
+	file, err := newMyFile(localFile.Name(), img, string(format), int64(encoded.Len()))
+	if err != nil {
+		log.Println(err)
+		return "", ErrFS
+	}
+
+	s.files[localFile.Name()] = file
+
+	log.Printf("stitched file: %v\n", localFile.Name())
+
+	return localFile.Name(), nil
+}
+
+func (fm *fileManager) SpriteFiles(s *Session, fileNames []string, params CutParams) (string, error) {
+	if s == nil {
+		return "", ErrNilSession
+	}
+
+	if len(fileNames) == 0 {
+		return "", imgprocessing.ErrEmptyGrid
+	}
+
+	s.fileMutex.Lock()
+	defer s.fileMutex.Unlock()
+
+	sprites := make([]imgprocessing.Sprite, 0, len(fileNames))
+
+	for _, fileName := range fileNames {
+		if _, ok := s.files[fileName]; !ok {
+			return "", ErrFileNotFound
+		}
+
+		img, _, err := imgprocessing.OpenImage(fileName)
+		if err != nil {
+			return "", fmt.Errorf("error processing image: %w", err)
+		}
+
+		sprites = append(sprites, imgprocessing.Sprite{Name: filepath.Base(fileName), Image: img})
+	}
+
+	// имя уникальное: постоянное совпало бы с архивом нарезки загруженного sprites.png
+	archive, err := os.CreateTemp(fmt.Sprintf("temp/%s", s.String()), "sprites-*.zip")
+	if err != nil {
+		e := fmt.Errorf("error on create archive file: %w", err)
+		log.Println(e)
+
+		return "", e
+	}
+	defer archive.Close()
+
+	zipWriter := zip.NewWriter(archive)
+
+	err = packSprites(zipWriter, sprites, "sprites", params)
+	if err == nil {
+		err = zipWriter.Close()
+	}
+
+	if err != nil {
+		e := fmt.Errorf("error on create archive file: %w", err)
+		log.Println(e)
+		archive.Close()
+		deleteFileIfExist(archive.Name())
+
+		return "", e
+	}
+
+	// лист удаляется вместе с любым из файлов, из которых собран
+	s.sheets[archive.Name()] = append([]string(nil), fileNames...)
+
+	return archive.Name(), nil
+}
+
 func (fm *fileManager) UploadFile(session *Session, uploadingFile io.Reader, fileName string) error {
 	if session == nil {
 		return ErrNilSession
 	}
 
+	fileBytes, err := io.ReadAll(uploadingFile)
+	if err != nil {
+		log.Printf("error reading uploading file: %s", err)
+		return ErrFS
+	}
+
+	// декодируем сразу, чтобы не хранить то, что не сможем нарезать
+	img, format, err := image.Decode(bytes.NewReader(fileBytes))
+	if err != nil {
+		log.Printf("error decoding uploading file: %s", err)
+		return fmt.Errorf("%w: %v", ErrNotImage, err)
+	}
+
 	session.fileMutex.Lock()
 	defer session.fileMutex.Unlock()
 
@@ -161,19 +424,13 @@ func (fm *fileManager) UploadFile(session *Session, uploadingFile io.Reader, fil
 		return ErrFS
 	}
 
-	localFile, err := os.Create(fmt.Sprintf("temp/%s/%s", session.String(), fileName))
+	localFile, err = os.Create(fmt.Sprintf("temp/%s/%s", session.String(), fileName))
 	if err != nil {
 		log.Printf("error creating file: %s", err)
 		return ErrFS
 	}
 	defer localFile.Close()
 
-	fileBytes, err := io.ReadAll(uploadingFile)
-	if err != nil {
-		log.Printf("error reading uploading file: %s", err)
-		return ErrFS
-	}
-
 	n, err := localFile.Write(fileBytes)
 	if err != nil {
 		log.Printf("error writing bytes to localfile: %s", err)
@@ -192,12 +449,14 @@ func (fm *fileManager) UploadFile(session *Session, uploadingFile io.Reader, fil
 		return ErrFS
 	}
 
-	session.files[localFile.Name()] = MyFile{
-		OriginalFile: localFile.Name(),
-		Archive:      "",
-		uploaded:     time.Now(),
+	file, err := newMyFile(localFile.Name(), img, format, int64(n))
+	if err != nil {
+		log.Println(err)
+		return ErrFS
 	}
 
+	session.files[localFile.Name()] = file
+
 	log.Printf("uploaded file: %v\n", localFile.Name())
 
 	return nil
@@ -220,6 +479,95 @@ func (fm *fileManager) GetArchiveName(session *Session, fileName string) (string
 	return f.Archive, nil
 }
 
+func (fm *fileManager) ListTiles(s *Session, fileName string) (*imgprocessing.TileSet, error) {
+	if s == nil {
+		return nil, ErrNilSession
+	}
+
+	s.fileMutex.Lock()
+	defer s.fileMutex.Unlock()
+
+	archive, err := s.openArchive(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	return imgprocessing.ReadTileSet(&archive.Reader)
+}
+
+func (fm *fileManager) ReadTile(s *Session, fileName string, tileName string) ([]byte, error) {
+	if s == nil {
+		return nil, ErrNilSession
+	}
+
+	s.fileMutex.Lock()
+	defer s.fileMutex.Unlock()
+
+	archive, err := s.openArchive(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	set, err := imgprocessing.ReadTileSet(&archive.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := imgprocessing.OpenTile(&archive.Reader, set, tileName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFileNotFound, err)
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("unable read tile: %w", err)
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// openArchive opens archive with tiles of cut file, fileMutex must be locked.
+func (s *Session) openArchive(fileName string) (*zip.ReadCloser, error) {
+	f, ok := s.files[fileName]
+	if !ok {
+		return nil, ErrFileNotFound
+	}
+
+	if err := checkFileExist(f.Archive); err != nil {
+		return nil, err
+	}
+
+	if !f.Tiles {
+		return nil, ErrNoTiles
+	}
+
+	archive, err := zip.OpenReader(f.Archive)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", imgprocessing.ErrBadArchive, err)
+	}
+
+	return archive, nil
+}
+
+func (fm *fileManager) GetThumbnail(s *Session, fileName string) ([]byte, error) {
+	if s == nil {
+		return nil, ErrNilSession
+	}
+
+	s.fileMutex.Lock()
+	defer s.fileMutex.Unlock()
+
+	f, ok := s.files[fileName]
+	if !ok || f.thumbnail == nil {
+		return nil, ErrFileNotFound
+	}
+
+	return f.thumbnail, nil
+}
+
 func (fm *fileManager) DeleteFile(session *Session, fileName string) error {
 	if session == nil {
 		return ErrNilSession
@@ -232,10 +580,26 @@ func (fm *fileManager) DeleteFile(session *Session, fileName string) error {
 		return err
 	}
 
+	for sheet, packed := range session.sheets {
+		for _, f := range packed {
+			if f != fileName {
+				continue
+			}
+
+			if err := deleteFileIfExist(sheet); err != nil {
+				return err
+			}
+
+			delete(session.sheets, sheet)
+
+			break
+		}
+	}
+
 	return nil
 }
 
-func (fm *fileManager) setArchivePath(s *Session, targetFileName string, archiveName string) error {
+func (fm *fileManager) setArchivePath(s *Session, targetFileName string, archiveName string, tiles bool) error {
 	if s == nil {
 		return ErrNilSession
 	}
@@ -246,6 +610,7 @@ func (fm *fileManager) setArchivePath(s *Session, targetFileName string, archive
 	}
 
 	file.Archive = archiveName
+	file.Tiles = tiles
 	s.files[targetFileName] = file
 
 	return nil
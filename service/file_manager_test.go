@@ -2,10 +2,15 @@ package service
 
 import (
 	"archive/zip"
+	"bytes"
+	"errors"
 	"fmt"
+	"image"
+	"image/png"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 
@@ -38,6 +43,34 @@ func Test_FileManager(t *testing.T) {
 		assert.Equal(t, len(fm.sessions), 3)
 	})
 
+	t.Run("csrf tokens", func(t *testing.T) {
+		assert.Equal(t, len(testSession1.CSRFToken()), 64)
+		assert.Equal(t, testSession1.CSRFToken() != testSession2.CSRFToken(), true)
+		// токен не меняется в течение жизни сессии
+		s, _ := fm.Find(testSession1.String())
+		assert.Equal(t, s.CSRFToken(), testSession1.CSRFToken())
+	})
+
+	t.Run("flash messages", func(t *testing.T) {
+		flashes, err := fm.PopFlashes(testSession3)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(flashes), 0)
+
+		assert.Equal(t, fm.AddFlash(testSession3, Flash{Key: "done.upload", Args: []string{"a.png"}}), nil)
+		assert.Equal(t, fm.AddFlash(testSession3, Flash{Key: "done.cut", Args: []string{"a.png"}}), nil)
+
+		flashes, err = fm.PopFlashes(testSession3)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, flashes, []Flash{{Key: "done.upload", Args: []string{"a.png"}}, {Key: "done.cut", Args: []string{"a.png"}}})
+
+		// сообщение показывается один раз
+		flashes, err = fm.PopFlashes(testSession3)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(flashes), 0)
+
+		assert.Equal(t, fm.AddFlash(nil, Flash{}), ErrNilSession)
+	})
+
 	testfile, err := os.Open("mem.jpg")
 	assert.Equal(t, err, nil)
 	defer testfile.Close()
@@ -56,6 +89,28 @@ func Test_FileManager(t *testing.T) {
 	})
 	defer fm.RemoveAll()
 
+	t.Run("metadata and thumbnail", func(t *testing.T) {
+		files, err := fm.GetFiles(testSession1)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(files), 1)
+		assert.Equal(t, files[0].Info, imgprocessing.ImageInfo{Width: 320, Height: 339, Format: "jpeg", ColorModel: "YCbCr 4:2:0"})
+
+		stat, err := testfile.Stat()
+		assert.Equal(t, err, nil)
+		assert.Equal(t, files[0].Size, stat.Size())
+
+		thumbnail, err := fm.GetThumbnail(testSession1, files[0].OriginalFile)
+		assert.Equal(t, err, nil)
+		img, err := png.Decode(bytes.NewReader(thumbnail))
+		assert.Equal(t, err, nil)
+		assert.Equal(t, img.Bounds().Size(), image.Pt(121, 128))
+	})
+
+	t.Run("uploading not an image", func(t *testing.T) {
+		err := fm.UploadFile(testSession3, strings.NewReader("not an image"), "fake.jpg")
+		assert.Equal(t, errors.Is(err, ErrNotImage), true)
+	})
+
 	counter := 0
 	walkFunc := func(path string, d fs.DirEntry, err error) error {
 		if !d.IsDir() {
@@ -71,14 +126,25 @@ func Test_FileManager(t *testing.T) {
 	})
 
 	t.Run("cutting files", func(t *testing.T) {
-		err = fm.CutFile(testSession1, fmt.Sprintf("temp/%s/testfile1.jpg", testSession1.String()), 32, 32)
+		err = fm.CutFile(testSession1, fmt.Sprintf("temp/%s/testfile1.jpg", testSession1.String()), CutParams{DX: 32, DY: 32})
 		assert.Equal(t, err, nil)
-		err = fm.CutFile(testSession2, fmt.Sprintf("temp/%s/testfile2.jpg", testSession2.String()), 100, 100)
+		err = fm.CutFile(testSession2, fmt.Sprintf("temp/%s/testfile2.jpg", testSession2.String()), CutParams{DX: 100, DY: 100})
 		assert.Equal(t, err, nil)
-		err = fm.CutFile(testSession3, fmt.Sprintf("temp/%s/testfile3.jpg", testSession3.String()), 10, 10)
+		err = fm.CutFile(testSession3, fmt.Sprintf("temp/%s/testfile3.jpg", testSession3.String()), CutParams{DX: 10, DY: 10})
 		assert.Equal(t, err, fmt.Errorf("error on cut img: %w", imgprocessing.ErrSmallCut))
 	})
 
+	t.Run("cutting files of other session", func(t *testing.T) {
+		for _, name := range []string{
+			fmt.Sprintf("temp/%s/testfile2.jpg", testSession2.String()),
+			fmt.Sprintf("temp/%s/../%s/testfile1.jpg", testSession1.String(), testSession1.String()),
+			"mem.jpg",
+		} {
+			err := fm.CutFile(testSession1, name, CutParams{DX: 32, DY: 32})
+			assert.Equal(t, err, ErrFileNotFound, name)
+		}
+	})
+
 	t.Run("two archives created", func(t *testing.T) {
 		counter = 0
 		filepath.WalkDir("temp", walkFunc)
@@ -115,12 +181,33 @@ func Test_FileManager(t *testing.T) {
 		archive1, err := zip.OpenReader(archiveName1)
 		assert.Equal(t, err, nil)
 		defer archive1.Close()
-		assert.Equal(t, len(archive1.File), 110) // 320x339px / 32x32px = (320/32) x (339/32) = 10x11 = 110
+		assert.Equal(t, len(archive1.File), 111) // 320x339px / 32x32px = (320/32) x (339/32) = 10x11 = 110 + manifest
 
 		archive2, err := zip.OpenReader(archiveName2)
 		assert.Equal(t, err, nil)
 		defer archive2.Close()
-		assert.Equal(t, len(archive2.File), 16) // 320x339px / 100x100px = (320/100) x (339/100) = 4x4 = 16
+		assert.Equal(t, len(archive2.File), 17) // 320x339px / 100x100px = (320/100) x (339/100) = 4x4 = 16 + manifest
+	})
+
+	t.Run("browsing tiles", func(t *testing.T) {
+		fileName := fmt.Sprintf("temp/%s/testfile2.jpg", testSession2.String())
+
+		set, err := fm.ListTiles(testSession2, fileName)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(set.Tiles), 16)
+		assert.Equal(t, set.Width, 320)
+
+		tile, err := fm.ReadTile(testSession2, fileName, set.Tiles[15].File)
+		assert.Equal(t, err, nil)
+		img, _, err := image.Decode(bytes.NewReader(tile))
+		assert.Equal(t, err, nil)
+		assert.Equal(t, img.Bounds().Size(), image.Pt(20, 39))
+
+		_, err = fm.ReadTile(testSession2, fileName, imgprocessing.ManifestFile)
+		assert.Equal(t, errors.Is(err, ErrFileNotFound), true)
+
+		_, err = fm.ListTiles(testSession3, fmt.Sprintf("temp/%s/testfile3.jpg", testSession3.String()))
+		assert.Equal(t, err, ErrFileNotFound)
 	})
 
 	t.Run("delete files", func(t *testing.T) {
@@ -137,3 +224,103 @@ func Test_FileManager(t *testing.T) {
 		assert.Equal(t, counter, 1) // 5 -2 -2 = 1
 	})
 }
+
+func Test_FileManager_SpriteFiles(t *testing.T) {
+	fm := &fileManager{
+		sessionsMapMutex: sync.Mutex{},
+		sessions:         map[string]*Session{},
+	}
+
+	fm.RemoveAll()
+	defer fm.RemoveAll()
+
+	s := fm.New()
+	names := []string{"sprites.jpg", "b.jpg", "c.jpg"}
+	paths := make([]string, len(names))
+
+	for i, name := range names {
+		testfile, err := os.Open("mem.jpg")
+		assert.Equal(t, err, nil)
+		assert.Equal(t, fm.UploadFile(s, testfile, name), nil)
+		testfile.Close()
+
+		paths[i] = fmt.Sprintf("temp/%s/%s", s.String(), name)
+	}
+
+	// архив нарезки sprites.jpg называется sprites.zip, лист не должен его затереть
+	assert.Equal(t, fm.CutFile(s, paths[0], CutParams{DX: 100, DY: 100}), nil)
+
+	sheet1, err := fm.SpriteFiles(s, paths[:2], CutParams{Mode: ModeSprite})
+	assert.Equal(t, err, nil)
+	sheet2, err := fm.SpriteFiles(s, paths[1:], CutParams{Mode: ModeSprite})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sheet1 != sheet2, true)
+
+	cutArchive, err := fm.GetArchiveName(s, paths[0])
+	assert.Equal(t, err, nil)
+	assert.Equal(t, cutArchive != sheet1, true)
+
+	archive, err := zip.OpenReader(cutArchive)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(archive.File), 17) // 4x4 куска + манифест
+	archive.Close()
+
+	_, err = fm.SpriteFiles(s, []string{paths[0], "temp/other/a.jpg"}, CutParams{Mode: ModeSprite})
+	assert.Equal(t, err, ErrFileNotFound)
+
+	// удаление файла удаляет листы, в которые он попал
+	assert.Equal(t, fm.DeleteFile(s, paths[0]), nil)
+	assert.Equal(t, checkFileExist(sheet1), ErrFileNotFound)
+	assert.Equal(t, checkFileExist(sheet2), nil)
+	assert.Equal(t, len(s.sheets), 1)
+
+	assert.Equal(t, fm.DeleteFile(s, paths[2]), nil)
+	assert.Equal(t, checkFileExist(sheet2), ErrFileNotFound)
+	assert.Equal(t, len(s.sheets), 0)
+}
+
+func Test_FileManager_ListTilesModes(t *testing.T) {
+	fm := &fileManager{
+		sessionsMapMutex: sync.Mutex{},
+		sessions:         map[string]*Session{},
+	}
+
+	fm.RemoveAll()
+	defer fm.RemoveAll()
+
+	s := fm.New()
+
+	tests := []struct {
+		params CutParams
+		err    error
+	}{
+		{params: CutParams{Mode: ModeGrid, DX: 100, DY: 100}},
+		{params: CutParams{Mode: ModeGuides, Columns: []int{100, 220}}},
+		{params: CutParams{Mode: ModeRegions, Regions: []imgprocessing.Region{{Name: "logo", Rect: imgprocessing.Rect{Width: 40, Height: 40}}}}},
+		{params: CutParams{Mode: ModeAuto, Background: "#000000"}},
+		{params: CutParams{Mode: ModeSprite, DX: 100, DY: 100}, err: ErrNoTiles},
+		{params: CutParams{Mode: ModeDZI}, err: ErrNoTiles},
+		{params: CutParams{Mode: ModeXYZ}, err: ErrNoTiles},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.params.Mode), func(t *testing.T) {
+			testfile, err := os.Open("mem.jpg")
+			assert.Equal(t, err, nil)
+			defer testfile.Close()
+
+			name := string(tc.params.Mode) + ".jpg"
+			fileName := fmt.Sprintf("temp/%s/%s", s.String(), name)
+
+			assert.Equal(t, fm.UploadFile(s, testfile, name), nil)
+			assert.Equal(t, fm.CutFile(s, fileName, tc.params), nil)
+			assert.Equal(t, s.files[fileName].Tiles, tc.err == nil)
+
+			_, err = fm.ListTiles(s, fileName)
+			assert.Equal(t, err, tc.err)
+
+			_, err = fm.ReadTile(s, fileName, "a.jpg")
+			assert.Equal(t, errors.Is(err, ErrNoTiles), tc.err != nil)
+		})
+	}
+}
@@ -5,6 +5,8 @@
 package service
 
 import (
+	image "image"
+	imgprocessing "imgcutter/imgprocessing"
 	io "io"
 	reflect "reflect"
 
@@ -34,6 +36,20 @@ func (m *MockSessionService) EXPECT() *MockSessionServiceMockRecorder {
 	return m.recorder
 }
 
+// AddFlash mocks base method.
+func (m *MockSessionService) AddFlash(session *Session, f Flash) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddFlash", session, f)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddFlash indicates an expected call of AddFlash.
+func (mr *MockSessionServiceMockRecorder) AddFlash(session, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFlash", reflect.TypeOf((*MockSessionService)(nil).AddFlash), session, f)
+}
+
 // Find mocks base method.
 func (m *MockSessionService) Find(id string) (*Session, bool) {
 	m.ctrl.T.Helper()
@@ -63,6 +79,21 @@ func (mr *MockSessionServiceMockRecorder) New() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "New", reflect.TypeOf((*MockSessionService)(nil).New))
 }
 
+// PopFlashes mocks base method.
+func (m *MockSessionService) PopFlashes(session *Session) ([]Flash, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PopFlashes", session)
+	ret0, _ := ret[0].([]Flash)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PopFlashes indicates an expected call of PopFlashes.
+func (mr *MockSessionServiceMockRecorder) PopFlashes(session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PopFlashes", reflect.TypeOf((*MockSessionService)(nil).PopFlashes), session)
+}
+
 // RemoveAll mocks base method.
 func (m *MockSessionService) RemoveAll() error {
 	m.ctrl.T.Helper()
@@ -115,17 +146,17 @@ func (m *MockFileService) EXPECT() *MockFileServiceMockRecorder {
 }
 
 // CutFile mocks base method.
-func (m *MockFileService) CutFile(s *Session, fileName string, dX, dY int) error {
+func (m *MockFileService) CutFile(s *Session, fileName string, params CutParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CutFile", s, fileName, dX, dY)
+	ret := m.ctrl.Call(m, "CutFile", s, fileName, params)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CutFile indicates an expected call of CutFile.
-func (mr *MockFileServiceMockRecorder) CutFile(s, fileName, dX, dY interface{}) *gomock.Call {
+func (mr *MockFileServiceMockRecorder) CutFile(s, fileName, params interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CutFile", reflect.TypeOf((*MockFileService)(nil).CutFile), s, fileName, dX, dY)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CutFile", reflect.TypeOf((*MockFileService)(nil).CutFile), s, fileName, params)
 }
 
 // DeleteFile mocks base method.
@@ -172,6 +203,96 @@ func (mr *MockFileServiceMockRecorder) GetFiles(s interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFiles", reflect.TypeOf((*MockFileService)(nil).GetFiles), s)
 }
 
+// GetThumbnail mocks base method.
+func (m *MockFileService) GetThumbnail(s *Session, fileName string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetThumbnail", s, fileName)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetThumbnail indicates an expected call of GetThumbnail.
+func (mr *MockFileServiceMockRecorder) GetThumbnail(s, fileName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetThumbnail", reflect.TypeOf((*MockFileService)(nil).GetThumbnail), s, fileName)
+}
+
+// ListTiles mocks base method.
+func (m *MockFileService) ListTiles(s *Session, fileName string) (*imgprocessing.TileSet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTiles", s, fileName)
+	ret0, _ := ret[0].(*imgprocessing.TileSet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTiles indicates an expected call of ListTiles.
+func (mr *MockFileServiceMockRecorder) ListTiles(s, fileName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTiles", reflect.TypeOf((*MockFileService)(nil).ListTiles), s, fileName)
+}
+
+// PreviewFile mocks base method.
+func (m *MockFileService) PreviewFile(s *Session, fileName string, params CutParams, maxSize int) (image.Image, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreviewFile", s, fileName, params, maxSize)
+	ret0, _ := ret[0].(image.Image)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreviewFile indicates an expected call of PreviewFile.
+func (mr *MockFileServiceMockRecorder) PreviewFile(s, fileName, params, maxSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreviewFile", reflect.TypeOf((*MockFileService)(nil).PreviewFile), s, fileName, params, maxSize)
+}
+
+// ReadTile mocks base method.
+func (m *MockFileService) ReadTile(s *Session, fileName, tileName string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadTile", s, fileName, tileName)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadTile indicates an expected call of ReadTile.
+func (mr *MockFileServiceMockRecorder) ReadTile(s, fileName, tileName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadTile", reflect.TypeOf((*MockFileService)(nil).ReadTile), s, fileName, tileName)
+}
+
+// SpriteFiles mocks base method.
+func (m *MockFileService) SpriteFiles(s *Session, fileNames []string, params CutParams) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpriteFiles", s, fileNames, params)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SpriteFiles indicates an expected call of SpriteFiles.
+func (mr *MockFileServiceMockRecorder) SpriteFiles(s, fileNames, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpriteFiles", reflect.TypeOf((*MockFileService)(nil).SpriteFiles), s, fileNames, params)
+}
+
+// StitchFile mocks base method.
+func (m *MockFileService) StitchFile(s *Session, archive io.ReaderAt, size int64, archiveName string, format imgprocessing.Format) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StitchFile", s, archive, size, archiveName, format)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StitchFile indicates an expected call of StitchFile.
+func (mr *MockFileServiceMockRecorder) StitchFile(s, archive, size, archiveName, format interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StitchFile", reflect.TypeOf((*MockFileService)(nil).StitchFile), s, archive, size, archiveName, format)
+}
+
 // UploadFile mocks base method.
 func (m *MockFileService) UploadFile(s *Session, uploadingFile io.Reader, fileName string) error {
 	m.ctrl.T.Helper()
@@ -185,3 +306,84 @@ func (mr *MockFileServiceMockRecorder) UploadFile(s, uploadingFile, fileName int
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadFile", reflect.TypeOf((*MockFileService)(nil).UploadFile), s, uploadingFile, fileName)
 }
+
+// MockPresetService is a mock of PresetService interface.
+type MockPresetService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPresetServiceMockRecorder
+}
+
+// MockPresetServiceMockRecorder is the mock recorder for MockPresetService.
+type MockPresetServiceMockRecorder struct {
+	mock *MockPresetService
+}
+
+// NewMockPresetService creates a new mock instance.
+func NewMockPresetService(ctrl *gomock.Controller) *MockPresetService {
+	mock := &MockPresetService{ctrl: ctrl}
+	mock.recorder = &MockPresetServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPresetService) EXPECT() *MockPresetServiceMockRecorder {
+	return m.recorder
+}
+
+// DeletePreset mocks base method.
+func (m *MockPresetService) DeletePreset(s *Session, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePreset", s, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePreset indicates an expected call of DeletePreset.
+func (mr *MockPresetServiceMockRecorder) DeletePreset(s, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePreset", reflect.TypeOf((*MockPresetService)(nil).DeletePreset), s, name)
+}
+
+// FindPreset mocks base method.
+func (m *MockPresetService) FindPreset(s *Session, name string) (Preset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPreset", s, name)
+	ret0, _ := ret[0].(Preset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindPreset indicates an expected call of FindPreset.
+func (mr *MockPresetServiceMockRecorder) FindPreset(s, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPreset", reflect.TypeOf((*MockPresetService)(nil).FindPreset), s, name)
+}
+
+// GetPresets mocks base method.
+func (m *MockPresetService) GetPresets(s *Session) ([]Preset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPresets", s)
+	ret0, _ := ret[0].([]Preset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPresets indicates an expected call of GetPresets.
+func (mr *MockPresetServiceMockRecorder) GetPresets(s interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPresets", reflect.TypeOf((*MockPresetService)(nil).GetPresets), s)
+}
+
+// SavePreset mocks base method.
+func (m *MockPresetService) SavePreset(s *Session, p Preset) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePreset", s, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SavePreset indicates an expected call of SavePreset.
+func (mr *MockPresetServiceMockRecorder) SavePreset(s, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePreset", reflect.TypeOf((*MockPresetService)(nil).SavePreset), s, p)
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrPresetNotFound = errors.New("preset not found")
+	ErrPresetReadOnly = errors.New("built-in preset can not be changed")
+	ErrInvalidPreset  = errors.New("invalid preset")
+)
+
+// Preset is a named set of cut parameters.
+// Built-in presets are loaded from config file and shared by all sessions,
+// user-defined ones live in session and die with it.
+type Preset struct {
+	Name string `json:"name"`
+	CutParams
+	BuiltIn bool `json:"-"` // export to templates
+}
+
+// LoadPresets reads built-in presets from json file of fsys with array of presets.
+func LoadPresets(fsys fs.FS, fileName string) ([]Preset, error) {
+	b, err := fs.ReadFile(fsys, fileName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read presets file: %w", err)
+	}
+
+	var presets []Preset
+	if err := json.Unmarshal(b, &presets); err != nil {
+		return nil, fmt.Errorf("unable to parse presets file: %w", err)
+	}
+
+	for i := range presets {
+		presets[i].CutParams = presets[i].CutParams.withDefaults()
+		presets[i].BuiltIn = true
+
+		if err := presets[i].validate(); err != nil {
+			return nil, fmt.Errorf("preset %q: %w", presets[i].Name, err)
+		}
+	}
+
+	return presets, nil
+}
+
+func (p Preset) validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("%w: empty name", ErrInvalidPreset)
+	}
+
+	if err := p.CutParams.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPreset, err)
+	}
+
+	return nil
+}
+
+type presetManager struct {
+	builtIn []Preset
+}
+
+// GetPresets returns built-in presets in order of config file followed by session ones sorted by name.
+func (pm *presetManager) GetPresets(s *Session) ([]Preset, error) {
+	if s == nil {
+		return nil, ErrNilSession
+	}
+
+	s.presetMutex.Lock()
+	defer s.presetMutex.Unlock()
+
+	output := make([]Preset, 0, len(pm.builtIn)+len(s.presets))
+	output = append(output, pm.builtIn...)
+
+	users := make([]Preset, 0, len(s.presets))
+	for _, p := range s.presets {
+		users = append(users, p)
+	}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+
+	return append(output, users...), nil
+}
+
+func (pm *presetManager) FindPreset(s *Session, name string) (Preset, error) {
+	if s == nil {
+		return Preset{}, ErrNilSession
+	}
+
+	if p, ok := pm.findBuiltIn(name); ok {
+		return p, nil
+	}
+
+	s.presetMutex.Lock()
+	defer s.presetMutex.Unlock()
+
+	p, ok := s.presets[name]
+	if !ok {
+		return Preset{}, ErrPresetNotFound
+	}
+
+	return p, nil
+}
+
+// SavePreset creates or overwrites session preset.
+func (pm *presetManager) SavePreset(s *Session, p Preset) error {
+	if s == nil {
+		return ErrNilSession
+	}
+
+	p.Name = strings.TrimSpace(p.Name)
+	p.CutParams = p.CutParams.withDefaults()
+	p.BuiltIn = false
+
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	if _, ok := pm.findBuiltIn(p.Name); ok {
+		return ErrPresetReadOnly
+	}
+
+	s.presetMutex.Lock()
+	defer s.presetMutex.Unlock()
+
+	if s.presets == nil {
+		s.presets = map[string]Preset{}
+	}
+
+	s.presets[p.Name] = p
+
+	return nil
+}
+
+func (pm *presetManager) DeletePreset(s *Session, name string) error {
+	if s == nil {
+		return ErrNilSession
+	}
+
+	if _, ok := pm.findBuiltIn(name); ok {
+		return ErrPresetReadOnly
+	}
+
+	s.presetMutex.Lock()
+	defer s.presetMutex.Unlock()
+
+	if _, ok := s.presets[name]; !ok {
+		return ErrPresetNotFound
+	}
+
+	delete(s.presets, name)
+
+	return nil
+}
+
+func (pm *presetManager) findBuiltIn(name string) (Preset, bool) {
+	for _, p := range pm.builtIn {
+		if p.Name == name {
+			return p, true
+		}
+	}
+
+	return Preset{}, false
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"imgcutter/static"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func Test_LoadPresets(t *testing.T) {
+	t.Run("built-in", func(t *testing.T) {
+		presets, err := LoadPresets(static.Configs, "configs/presets.json")
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(presets) > 0, true)
+
+		for _, p := range presets {
+			assert.Equal(t, p.BuiltIn, true)
+		}
+	})
+
+	fsys := fstest.MapFS{
+		"ok.json":      {Data: []byte(`[{"name": "icons", "dX": 64, "dY": 64}]`)},
+		"invalid.json": {Data: []byte(`[{"name": "tiny", "dX": 1, "dY": 1}]`)},
+		"broken.json":  {Data: []byte(`{`)},
+	}
+
+	presets, err := LoadPresets(fsys, "ok.json")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, presets[0].Mode, ModeGrid)
+
+	_, err = LoadPresets(fsys, "invalid.json")
+	assert.Equal(t, errors.Is(err, ErrInvalidPreset), true)
+
+	_, err = LoadPresets(fsys, "broken.json")
+	assert.Equal(t, err != nil, true)
+
+	_, err = LoadPresets(fsys, "missing.json")
+	assert.Equal(t, err != nil, true)
+}
+
+func Test_PresetManager(t *testing.T) {
+	pm := &presetManager{builtIn: []Preset{
+		{Name: "zoom", CutParams: CutParams{DX: 64, DY: 64, Mode: ModeGrid}, BuiltIn: true},
+		{Name: "icons", CutParams: CutParams{DX: 32, DY: 32, Mode: ModeGrid}, BuiltIn: true},
+	}}
+
+	s1, s2 := &Session{}, &Session{}
+
+	assert.Equal(t, pm.SavePreset(s1, Preset{Name: "banner", CutParams: CutParams{DX: 600, DY: 200}}), nil)
+	assert.Equal(t, pm.SavePreset(s1, Preset{Name: " avatar ", CutParams: CutParams{DX: 100, DY: 100}}), nil)
+
+	t.Run("saving presets", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			preset Preset
+			err    error
+		}{
+			{name: "new", preset: Preset{Name: "thumbs", CutParams: CutParams{DX: 50, DY: 50}}},
+			{name: "overwrite", preset: Preset{Name: "banner", CutParams: CutParams{DX: 640, DY: 200}}},
+			{name: "built-in", preset: Preset{Name: "icons", CutParams: CutParams{DX: 48, DY: 48}}, err: ErrPresetReadOnly},
+			{name: "empty name", preset: Preset{Name: " ", CutParams: CutParams{DX: 50, DY: 50}}, err: ErrInvalidPreset},
+			{name: "invalid params", preset: Preset{Name: "tiny", CutParams: CutParams{DX: 1, DY: 1}}, err: ErrInvalidPreset},
+			{name: "nil session", err: ErrNilSession},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				s := s1
+				if tc.err == ErrNilSession {
+					s = nil
+				}
+
+				err := pm.SavePreset(s, tc.preset)
+				assert.Equal(t, errors.Is(err, tc.err), true)
+				assert.Equal(t, err == nil, tc.err == nil)
+			})
+		}
+
+		p, err := pm.FindPreset(s1, "banner")
+		assert.Equal(t, err, nil)
+		assert.Equal(t, p.DX, 640)
+		assert.Equal(t, p.Mode, ModeGrid)
+	})
+
+	t.Run("finding presets", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			session *Session
+			preset  string
+			builtIn bool
+			err     error
+		}{
+			{name: "session", session: s1, preset: "avatar"},
+			{name: "built-in", session: s1, preset: "icons", builtIn: true},
+			{name: "built-in in other session", session: s2, preset: "zoom", builtIn: true},
+			{name: "other session", session: s2, preset: "avatar", err: ErrPresetNotFound},
+			{name: "missing", session: s1, preset: "missing", err: ErrPresetNotFound},
+			{name: "nil session", preset: "icons", err: ErrNilSession},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				p, err := pm.FindPreset(tc.session, tc.preset)
+				assert.Equal(t, err, tc.err)
+
+				if tc.err == nil {
+					assert.Equal(t, p.Name, tc.preset)
+					assert.Equal(t, p.BuiltIn, tc.builtIn)
+				}
+			})
+		}
+	})
+
+	t.Run("listing presets", func(t *testing.T) {
+		names := func(presets []Preset) []string {
+			out := []string{}
+			for _, p := range presets {
+				out = append(out, p.Name)
+			}
+
+			return out
+		}
+
+		// встроенные идут в порядке файла, свои — по имени
+		presets, err := pm.GetPresets(s1)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, names(presets), []string{"zoom", "icons", "avatar", "banner", "thumbs"})
+
+		presets, err = pm.GetPresets(s2)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, names(presets), []string{"zoom", "icons"})
+
+		_, err = pm.GetPresets(nil)
+		assert.Equal(t, err, ErrNilSession)
+	})
+
+	t.Run("deleting presets", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			session *Session
+			preset  string
+			err     error
+		}{
+			{name: "session", session: s1, preset: "thumbs"},
+			{name: "deleted", session: s1, preset: "thumbs", err: ErrPresetNotFound},
+			{name: "built-in", session: s1, preset: "icons", err: ErrPresetReadOnly},
+			{name: "other session", session: s2, preset: "avatar", err: ErrPresetNotFound},
+			{name: "nil session", preset: "avatar", err: ErrNilSession},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				assert.Equal(t, pm.DeletePreset(tc.session, tc.preset), tc.err)
+			})
+		}
+
+		_, err := pm.FindPreset(s1, "avatar")
+		assert.Equal(t, err, nil)
+		_, err = pm.FindPreset(s1, "icons")
+		assert.Equal(t, err, nil)
+	})
+}
@@ -1,8 +1,11 @@
 package service
 
 import (
+	"image"
 	"io"
 	"sync"
+
+	"imgcutter/imgprocessing"
 )
 
 //go:generate mockgen -source=service.go -destination=mock_service.go -package=service
@@ -13,22 +16,47 @@ type SessionService interface {
 	// GetAll() []string // debug
 	RemoveAll() error
 	TerminateSession(session *Session) error
+	// AddFlash keeps message for the next page shown to session.
+	AddFlash(session *Session, f Flash) error
+	// PopFlashes returns messages of session in order they were added and forgets them.
+	PopFlashes(session *Session) ([]Flash, error)
 }
 
 type FileService interface {
 	GetFiles(s *Session) ([]MyFile, error)
 	UploadFile(s *Session, uploadingFile io.Reader, fileName string) error
-	CutFile(s *Session, fileName string, dX int, dY int) error
+	CutFile(s *Session, fileName string, params CutParams) error
 	DeleteFile(s *Session, fileName string) error
 	GetArchiveName(s *Session, fileName string) (string, error)
+	// ListTiles returns layout of tiles in archive of cut file, ErrNoTiles for sprite sheets and tile pyramids.
+	ListTiles(s *Session, fileName string) (*imgprocessing.TileSet, error)
+	// ReadTile returns content of a single tile stored in archive of cut file.
+	ReadTile(s *Session, fileName string, tileName string) ([]byte, error)
+	// GetThumbnail returns png thumbnail of uploaded file.
+	GetThumbnail(s *Session, fileName string) ([]byte, error)
+	// StitchFile reassembles tiles of archive into image and adds it to session files, returns its name.
+	StitchFile(s *Session, archive io.ReaderAt, size int64, archiveName string, format imgprocessing.Format) (string, error)
+	// SpriteFiles packs uploaded files into a sprite sheet archive, returns its name.
+	SpriteFiles(s *Session, fileNames []string, params CutParams) (string, error)
+	// PreviewFile renders downscaled file with outlines of tiles it would be cut into.
+	PreviewFile(s *Session, fileName string, params CutParams, maxSize int) (image.Image, error)
+}
+
+type PresetService interface {
+	GetPresets(s *Session) ([]Preset, error)
+	FindPreset(s *Session, name string) (Preset, error)
+	SavePreset(s *Session, p Preset) error
+	DeletePreset(s *Session, name string) error
 }
 
 type Service struct {
 	Files   FileService
 	Session SessionService
+	Presets PresetService
 }
 
-func NewService() Service {
+// NewService takes built-in presets available to every session.
+func NewService(presets []Preset) Service {
 	mem := &fileManager{
 		sessionsMapMutex: sync.Mutex{},
 		sessions:         map[string]*Session{},
@@ -37,5 +65,6 @@ func NewService() Service {
 	return Service{
 		Files:   mem,
 		Session: mem,
+		Presets: &presetManager{builtIn: presets},
 	}
 }
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
@@ -13,6 +15,12 @@ var (
 	ErrSessionNotFound = errors.New("session not found")
 )
 
+// Flash is outcome of action shown once on the next page, Key and Args are translated by web UI.
+type Flash struct {
+	Key  string
+	Args []string
+}
+
 func (fm *fileManager) Find(id string) (ses *Session, ok bool) {
 	_, err := uuid.Parse(id)
 	if err != nil {
@@ -32,6 +40,9 @@ func (fm *fileManager) New() *Session {
 			id:        uuid.New(),
 			fileMutex: sync.Mutex{},
 			files:     map[string]MyFile{},
+			sheets:    map[string][]string{},
+			presets:   map[string]Preset{},
+			csrfToken: newCSRFToken(),
 		}
 		if _, ok := fm.sessions[session.id.String()]; !ok {
 			break
@@ -45,6 +56,15 @@ func (fm *fileManager) New() *Session {
 	return &session
 }
 
+// newCSRFToken returns random token, uuid is not used since it is not meant to be secret.
+func newCSRFToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("unable to generate csrf token: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
 func (fm *fileManager) TerminateSession(session *Session) error {
 	if session == nil {
 		return ErrNilSession
@@ -78,3 +98,30 @@ func (fm *fileManager) GetAll() []string {
 
 	return out
 }
+
+func (fm *fileManager) AddFlash(session *Session, f Flash) error {
+	if session == nil {
+		return ErrNilSession
+	}
+
+	session.flashMutex.Lock()
+	defer session.flashMutex.Unlock()
+
+	session.flashes = append(session.flashes, f)
+
+	return nil
+}
+
+func (fm *fileManager) PopFlashes(session *Session) ([]Flash, error) {
+	if session == nil {
+		return nil, ErrNilSession
+	}
+
+	session.flashMutex.Lock()
+	defer session.flashMutex.Unlock()
+
+	flashes := session.flashes
+	session.flashes = nil
+
+	return flashes, nil
+}
@@ -0,0 +1,367 @@
+"use strict";
+
+// Single page frontend of imgcutter, it talks to api/ and reuses thumbnail, preview and gallery pages.
+// Links are relative to app/, so the service may be mounted under any path.
+(() => {
+  const $ = (id) => document.getElementById(id);
+
+  const state = {
+    files: [],
+    selected: null, // name of file in editor
+    csrf: "", // token of session, required by every request changing something
+    messages: {}, // catalog of locale of client, see t
+  };
+
+  // t translates message of key like the server does, %s, %q and %d are replaced by args in order.
+  // Untranslated page keeps its English text, unknown keys are returned as is.
+  function t(key, ...args) {
+    let i = 0;
+    return (state.messages[key] || key).replace(/%[sqd]/g, (verb) => {
+      const arg = args[i++];
+      return verb === "%q" ? JSON.stringify(String(arg)) : String(arg);
+    });
+  }
+
+  // переводит разметку: текст элементов с data-i18n и alt картинок с data-i18n-alt
+  function translatePage(root) {
+    for (const el of root.querySelectorAll("[data-i18n]")) {
+      el.textContent = t(el.dataset.i18n);
+    }
+    for (const el of root.querySelectorAll("[data-i18n-alt]")) {
+      el.alt = t(el.dataset.i18nAlt);
+    }
+  }
+
+  function showError(message) {
+    const el = $("error");
+    el.textContent = message;
+    el.hidden = !message;
+  }
+
+  async function request(url, options = {}) {
+    if (options.method && options.method !== "GET") {
+      options.headers = { ...options.headers, "X-CSRF-Token": state.csrf };
+    }
+
+    const resp = await fetch(url, options);
+    if (resp.status === 204) {
+      return null;
+    }
+
+    const body = await resp.json();
+    if (!resp.ok) {
+      throw new Error(body.error || resp.statusText);
+    }
+
+    return body;
+  }
+
+  function formatSize(bytes) {
+    const units = ["B", "KB", "MB", "GB"];
+    let i = 0;
+    while (bytes >= 1024 && i < units.length - 1) {
+      bytes /= 1024;
+      i++;
+    }
+
+    return (i === 0 ? bytes : bytes.toFixed(1)) + " " + units[i];
+  }
+
+  function fileURL(path, file, extra) {
+    const query = new URLSearchParams({ fileName: file.name, ...extra });
+    return path + "?" + query;
+  }
+
+  // список файлов
+
+  function renderFiles() {
+    const list = $("files");
+    list.replaceChildren();
+    $("noFiles").hidden = state.files.length > 0;
+
+    for (const file of state.files) {
+      const item = $("fileTemplate").content.firstElementChild.cloneNode(true);
+      item.classList.toggle("selected", file.name === state.selected);
+
+      item.querySelector(".thumbnail").src = fileURL("../thumbnail", file, { v: Date.parse(file.uploaded) });
+      item.querySelector(".name").textContent = file.baseName;
+      item.querySelector(".meta").textContent = [
+        file.info.width + "×" + file.info.height + " px",
+        file.info.format,
+        file.info.colorModel,
+        formatSize(file.size),
+        t("app.uploaded", new Date(file.uploaded).toLocaleTimeString()),
+      ].join(", ");
+
+      const download = item.querySelector(".download");
+      const gallery = item.querySelector(".gallery");
+      download.hidden = !file.cut;
+      // у спрайт-листов и пирамид тайлов нет манифеста, галерее нечего показать
+      gallery.hidden = !file.tiles;
+      download.href = fileURL("../api/archive", file);
+      gallery.href = fileURL("../gallery", file);
+
+      item.querySelector(".edit").addEventListener("click", () => selectFile(file.name));
+      item.querySelector(".delete").addEventListener("click", () => deleteFile(file));
+
+      list.append(item);
+    }
+  }
+
+  async function loadMessages() {
+    const body = await request("../api/messages");
+    state.messages = body.messages;
+    document.documentElement.lang = body.locale;
+    translatePage(document);
+    translatePage($("fileTemplate").content);
+  }
+
+  async function loadCSRF() {
+    state.csrf = (await request("../api/csrf")).token;
+  }
+
+  async function loadFiles() {
+    state.files = await request("../api/files");
+    renderFiles();
+  }
+
+  async function deleteFile(file) {
+    if (!confirm(t("app.confirm_delete", file.baseName))) {
+      return;
+    }
+
+    try {
+      await request(fileURL("../api/files", file), { method: "DELETE" });
+      if (state.selected === file.name) {
+        state.selected = null;
+        $("editor").hidden = true;
+      }
+      await loadFiles();
+    } catch (e) {
+      showError(e.message);
+    }
+  }
+
+  // загрузка, каждый файл отдельным запросом, чтобы показывать его прогресс
+
+  function upload(file) {
+    const item = document.createElement("li");
+    const name = document.createElement("span");
+    const bar = document.createElement("progress");
+    name.textContent = file.name;
+    bar.max = file.size;
+    bar.value = 0;
+    item.append(name, bar);
+    $("uploads").append(item);
+
+    return new Promise((resolve) => {
+      const xhr = new XMLHttpRequest();
+      const form = new FormData();
+      form.append("files", file);
+
+      xhr.upload.addEventListener("progress", (e) => {
+        bar.value = e.loaded;
+        bar.max = e.total;
+      });
+
+      xhr.addEventListener("load", () => {
+        const body = JSON.parse(xhr.responseText || "null");
+        if (xhr.status === 201) {
+          state.files = body;
+          renderFiles();
+          item.remove();
+        } else {
+          name.textContent = file.name + ": " + ((body && body.error) || xhr.statusText);
+          item.classList.add("error");
+        }
+        resolve();
+      });
+
+      xhr.addEventListener("error", () => {
+        name.textContent = file.name + ": " + t("app.network_error");
+        item.classList.add("error");
+        resolve();
+      });
+
+      xhr.open("POST", "../api/files");
+      xhr.setRequestHeader("X-CSRF-Token", state.csrf);
+      xhr.send(form);
+    });
+  }
+
+  async function uploadAll(files) {
+    showError("");
+    for (const file of files) {
+      await upload(file);
+    }
+  }
+
+  const dropZone = $("dropZone");
+  dropZone.addEventListener("dragover", (e) => {
+    e.preventDefault();
+    dropZone.classList.add("over");
+  });
+  dropZone.addEventListener("dragleave", () => dropZone.classList.remove("over"));
+  dropZone.addEventListener("drop", (e) => {
+    e.preventDefault();
+    dropZone.classList.remove("over");
+    uploadAll(e.dataTransfer.files);
+  });
+  $("fileInput").addEventListener("change", (e) => {
+    uploadAll(e.target.files);
+    e.target.value = "";
+  });
+
+  // нарезка
+
+  const form = $("cutForm");
+  const preview = $("preview");
+  const selection = $("selection");
+
+  function selectedFile() {
+    return state.files.find((f) => f.name === state.selected);
+  }
+
+  function selectFile(name) {
+    state.selected = name;
+    const file = selectedFile();
+
+    $("editor").hidden = false;
+    $("editorTitle").textContent = file.baseName;
+    $("cutResult").textContent = "";
+
+    // по умолчанию четверть изображения, но не меньше минимального куска
+    form.dX.value = Math.max(32, Math.ceil(file.info.width / 4));
+    form.dY.value = Math.max(32, Math.ceil(file.info.height / 4));
+
+    renderFiles();
+    updatePreview();
+    $("editor").scrollIntoView({ behavior: "smooth" });
+  }
+
+  function cutQuery() {
+    const query = new URLSearchParams();
+    for (const [key, value] of new FormData(form)) {
+      if (value !== "") {
+        query.set(key, value);
+      }
+    }
+    query.set("fileName", state.selected);
+
+    return query;
+  }
+
+  let previewTimer;
+  function updatePreview() {
+    clearTimeout(previewTimer);
+    previewTimer = setTimeout(() => {
+      preview.src = "../preview?" + cutQuery();
+    }, 300);
+  }
+
+  preview.addEventListener("error", () => showError(t("app.no_preview")));
+  preview.addEventListener("load", () => showError(""));
+  form.addEventListener("input", updatePreview);
+
+  // выделение прямоугольника задаёт размер куска в пикселях исходного изображения
+  let start = null;
+
+  function pointer(e) {
+    const r = preview.getBoundingClientRect();
+    return {
+      x: Math.min(Math.max(e.clientX - r.left, 0), r.width),
+      y: Math.min(Math.max(e.clientY - r.top, 0), r.height),
+    };
+  }
+
+  preview.parentElement.addEventListener("mousedown", (e) => {
+    e.preventDefault();
+    start = pointer(e);
+  });
+
+  window.addEventListener("mousemove", (e) => {
+    if (!start) {
+      return;
+    }
+
+    const p = pointer(e);
+    Object.assign(selection.style, {
+      left: Math.min(start.x, p.x) + "px",
+      top: Math.min(start.y, p.y) + "px",
+      width: Math.abs(p.x - start.x) + "px",
+      height: Math.abs(p.y - start.y) + "px",
+    });
+    selection.hidden = false;
+  });
+
+  window.addEventListener("mouseup", (e) => {
+    if (!start) {
+      return;
+    }
+
+    const p = pointer(e);
+    const scale = selectedFile().info.width / preview.getBoundingClientRect().width;
+    const width = Math.round(Math.abs(p.x - start.x) * scale);
+    const height = Math.round(Math.abs(p.y - start.y) * scale);
+
+    start = null;
+    selection.hidden = true;
+
+    if (width > 0 && height > 0) {
+      form.dX.value = width;
+      form.dY.value = height;
+      form.preset.value = "";
+      updatePreview();
+    }
+  });
+
+  form.addEventListener("submit", async (e) => {
+    e.preventDefault();
+    showError("");
+
+    const progress = $("cutProgress");
+    const result = $("cutResult");
+    progress.hidden = false;
+    result.textContent = "";
+
+    try {
+      const body = new FormData(form);
+      body.set("fileName", state.selected);
+      for (const box of form.querySelectorAll("input[type=checkbox]")) {
+        if (!box.checked) {
+          body.delete(box.name);
+        }
+      }
+
+      const file = await request("../api/cut", { method: "POST", body });
+      state.files = state.files.map((f) => (f.name === file.name ? file : f));
+      renderFiles();
+
+      const link = document.createElement("a");
+      link.href = fileURL("../api/archive", file);
+      link.textContent = t("app.download");
+      result.replaceChildren(link);
+    } catch (err) {
+      showError(err.message);
+    } finally {
+      progress.hidden = true;
+    }
+  });
+
+  async function loadPresets() {
+    const presets = await request("../api/presets");
+    for (const p of presets) {
+      const option = document.createElement("option");
+      option.value = p.name;
+      option.textContent = p.name + " (" + p.dX + "×" + p.dY + ", " + p.format + ")";
+      $("presets").append(option);
+    }
+  }
+
+  // токен запрашивается первым: параллельные запросы без куки создали бы разные сессии,
+  // сообщения — до списка файлов, чтобы он сразу рисовался переведённым
+  loadCSRF()
+    .then(loadMessages)
+    .then(() => Promise.all([loadFiles(), loadPresets()]))
+    .catch((e) => showError(e.message));
+})();
@@ -0,0 +1,27 @@
+"use strict";
+
+// предпросмотр: поля формы нарезки уходят в /preview как query, запрос откладывается, пока пользователь печатает.
+// csrf-токен в адрес не попадает, чтобы не светиться в логах и истории
+document.querySelectorAll(".cutForm").forEach(function (form) {
+  var img = form.querySelector(".preview");
+  var error = form.querySelector(".previewError");
+  var timer;
+
+  function refresh() {
+    var query = new URLSearchParams();
+    new FormData(form).forEach(function (value, key) {
+      if (typeof value === "string" && value !== "" && key !== "csrf_token") {
+        query.append(key, value);
+      }
+    });
+    img.src = "preview?" + query.toString();
+  }
+
+  img.onload = function () { img.hidden = false; error.hidden = true; };
+  img.onerror = function () { img.hidden = true; error.hidden = false; };
+  form.querySelector(".previewButton").onclick = refresh;
+  form.addEventListener("input", function () {
+    clearTimeout(timer);
+    timer = setTimeout(refresh, 400);
+  });
+});
@@ -0,0 +1,29 @@
+// Package static holds files of web frontend built into binary.
+package static
+
+import "embed"
+
+// App is the single page frontend, it is served at /app/ and talks to /api/.
+//
+//go:embed app
+var App embed.FS
+
+// Assets are scripts, styles and icons of server rendered pages, they are served at /static/.
+//
+//go:embed assets
+var Assets embed.FS
+
+// Templates are pages rendered by server, "<page>.html" each.
+//
+//go:embed templates
+var Templates embed.FS
+
+// Configs hold default settings, "presets.json" with built-in presets.
+//
+//go:embed configs
+var Configs embed.FS
+
+// Locales are message catalogs of web UI, "<locale>.json" each.
+//
+//go:embed locales
+var Locales embed.FS